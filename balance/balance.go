@@ -0,0 +1,119 @@
+// balance/balance.go — rekonsiliasi saldo lokal dengan saldo deposit Digiflazz
+package balance
+
+import (
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"context"
+	"log"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	SourceScheduled = "scheduled"
+	SourceManual    = "manual"
+
+	defaultDriftThreshold = 10000
+)
+
+// DriftThreshold adalah selisih (rupiah) yang dianggap tidak wajar.
+// Bisa dioverride lewat env BALANCE_DRIFT_THRESHOLD.
+func DriftThreshold() float64 {
+	if v := os.Getenv("BALANCE_DRIFT_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			return f
+		}
+		log.Printf("⚠️ BALANCE_DRIFT_THRESHOLD tidak valid: %q", v)
+	}
+	return defaultDriftThreshold
+}
+
+// Check mengambil saldo Digiflazz dan menyimpan snapshot perbandingan dengan
+// ProfilAplikasi.Saldo. Transaksi yang sedang diproses bisa membuat selisih
+// sementara, karena saldo lokal sudah dipotong sebelum Digiflazz memotong.
+func Check(ctx context.Context, db *gorm.DB, client *digiflazz.Client, source string) (*models.BalanceSnapshot, error) {
+	remote, err := client.Balance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var profil models.ProfilAplikasi
+	if err := db.WithContext(ctx).First(&profil).Error; err != nil {
+		return nil, err
+	}
+
+	threshold := DriftThreshold()
+	drift := profil.Saldo - remote.Deposit
+
+	snapshot := &models.BalanceSnapshot{
+		LocalSaldo:  profil.Saldo,
+		RemoteSaldo: remote.Deposit,
+		Drift:       drift,
+		Threshold:   threshold,
+		Flagged:     math.Abs(drift) > threshold,
+		Source:      source,
+	}
+	if err := db.WithContext(ctx).Create(snapshot).Error; err != nil {
+		return nil, err
+	}
+
+	if snapshot.Flagged {
+		slog.Warn("⚠️ Saldo lokal berbeda dengan saldo Digiflazz",
+			"local", snapshot.LocalSaldo,
+			"remote", snapshot.RemoteSaldo,
+			"drift", snapshot.Drift,
+			"threshold", threshold,
+		)
+	} else {
+		slog.Info("💰 Saldo dicek", "local", snapshot.LocalSaldo, "remote", snapshot.RemoteSaldo)
+	}
+	return snapshot, nil
+}
+
+// Correct mengambil snapshot baru lalu menyamakan ProfilAplikasi.Saldo dengan
+// saldo Digiflazz. Koreksi dicatat sebagai models.BalanceCorrection.
+func Correct(ctx context.Context, db *gorm.DB, client *digiflazz.Client, note string) (*models.BalanceCorrection, error) {
+	snapshot, err := Check(ctx, db, client, SourceManual)
+	if err != nil {
+		return nil, err
+	}
+
+	var correction models.BalanceCorrection
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var profil models.ProfilAplikasi
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&profil).Error; err != nil {
+			return err
+		}
+
+		correction = models.BalanceCorrection{
+			SnapshotID: snapshot.ID,
+			OldSaldo:   profil.Saldo,
+			NewSaldo:   snapshot.RemoteSaldo,
+			Difference: snapshot.RemoteSaldo - profil.Saldo,
+		}
+		if note != "" {
+			correction.Note = &note
+		}
+
+		if err := tx.Model(&profil).UpdateColumn("saldo", snapshot.RemoteSaldo).Error; err != nil {
+			return err
+		}
+		return tx.Create(&correction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("🛠️ Saldo dikoreksi",
+		"old", correction.OldSaldo,
+		"new", correction.NewSaldo,
+		"difference", correction.Difference,
+	)
+	return &correction, nil
+}
@@ -0,0 +1,132 @@
+// catalog/pasca.go — sinkronisasi price list pascabayar Digiflazz ke product_pascas
+package catalog
+
+import (
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PascaSyncResult adalah ringkasan hasil sync produk pascabayar
+type PascaSyncResult struct {
+	Total       int `json:"total"`
+	Created     int `json:"created"`
+	Updated     int `json:"updated"`
+	Deactivated int `json:"deactivated"`
+	Skipped     int `json:"skipped"`
+}
+
+// SyncPasca meng-upsert produk pascabayar berdasarkan buyer_sku_code dan
+// menonaktifkan SKU yang tidak lagi ada di price list
+func SyncPasca(ctx context.Context, db *gorm.DB, client *digiflazz.Client) (*PascaSyncResult, error) {
+	products, err := client.PriceListPasca(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PascaSyncResult{Total: len(products)}
+	now := time.Now()
+	seen := make([]string, 0, len(products))
+
+	for _, product := range products {
+		if product.BuyerSkuCode == "" || product.ProductName == "" {
+			result.Skipped++
+			continue
+		}
+		seen = append(seen, product.BuyerSkuCode)
+
+		var existing models.ProductPasca
+		err := db.WithContext(ctx).Where("buyer_sku_code = ?", product.BuyerSkuCode).First(&existing).Error
+
+		if err == nil {
+			// Produk yang dinonaktifkan admin tidak diaktifkan lagi oleh sync
+			isActive := existing.IsActive || existing.SyncRemovedAt != nil
+			err = db.WithContext(ctx).Model(&existing).Updates(map[string]interface{}{
+				"product_name":          product.ProductName,
+				"slug":                  slugify(product.Brand),
+				"category":              product.Category,
+				"brand":                 product.Brand,
+				"seller_name":           product.SellerName,
+				"buyer_product_status":  product.BuyerProductStatus,
+				"seller_product_status": product.SellerProductStatus,
+				"admin":                 fmt.Sprintf("%d", product.Admin.Int64()),
+				"commission":            fmt.Sprintf("%d", product.Commission.Int64()),
+				"desc":                  product.Desc,
+				"is_active":             isActive,
+				"sync_removed_at":       nil,
+				"last_sync_at":          &now,
+				"updated_at":            now,
+			}).Error
+			if err != nil {
+				slog.Error("Gagal update produk pasca", "sku", product.BuyerSkuCode, "err", err)
+				continue
+			}
+			result.Updated++
+			continue
+		}
+
+		// Harga tagihan pascabayar baru diketahui saat inquiry
+		newProduct := models.ProductPasca{
+			ProductName:         product.ProductName,
+			Slug:                slugify(product.Brand),
+			Category:            product.Category,
+			Brand:               product.Brand,
+			SellerName:          product.SellerName,
+			Price:               "0",
+			SellingPrice:        "0",
+			BuyerSkuCode:        product.BuyerSkuCode,
+			BuyerProductStatus:  product.BuyerProductStatus,
+			SellerProductStatus: product.SellerProductStatus,
+			StartCutOff:         "00:00",
+			EndCutOff:           "23:59",
+			Admin:               fmt.Sprintf("%d", product.Admin.Int64()),
+			Commission:          fmt.Sprintf("%d", product.Commission.Int64()),
+			Desc:                product.Desc,
+			IsActive:            true,
+			LastSyncAt:          &now,
+		}
+		if err := db.WithContext(ctx).Create(&newProduct).Error; err != nil {
+			slog.Error("Gagal create produk pasca", "sku", product.BuyerSkuCode, "err", err)
+			continue
+		}
+		result.Created++
+	}
+
+	// Price list kosong kemungkinan gangguan di Digiflazz, jangan nonaktifkan semua produk
+	if len(seen) > 0 {
+		deactivate := db.WithContext(ctx).Model(&models.ProductPasca{}).
+			Where("is_active = ? AND buyer_sku_code NOT IN ?", true, seen).
+			Updates(map[string]interface{}{"is_active": false, "sync_removed_at": &now, "updated_at": now})
+		if deactivate.Error != nil {
+			return result, deactivate.Error
+		}
+		result.Deactivated = int(deactivate.RowsAffected)
+	}
+
+	slog.Info("🔄 Sync produk pasca selesai",
+		"total", result.Total,
+		"created", result.Created,
+		"updated", result.Updated,
+		"deactivated", result.Deactivated,
+	)
+	return result, nil
+}
+
+// DedupePasca menghapus baris product_pascas dengan buyer_sku_code ganda
+// sebelum unique index dibuat AutoMigrate. Baris dengan id terkecil yang
+// dipertahankan karena baris itu yang selama ini di-update oleh sync (First)
+func DedupePasca(db *gorm.DB) (int64, error) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.ProductPasca{}) || migrator.HasIndex(&models.ProductPasca{}, "BuyerSkuCode") {
+		return 0, nil
+	}
+
+	result := db.Exec("DELETE dup FROM product_pascas dup " +
+		"JOIN product_pascas keep ON keep.buyer_sku_code = dup.buyer_sku_code AND keep.id < dup.id")
+	return result.RowsAffected, result.Error
+}
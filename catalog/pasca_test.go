@@ -0,0 +1,101 @@
+package catalog
+
+import (
+	"api-arveshop-go/testdb"
+	"context"
+	"database/sql/driver"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setValue mengambil nilai kolom dari UPDATE ... SET `a`=?,`b`=? WHERE ...
+func setValue(exec testdb.Exec, column string) (driver.Value, bool) {
+	start := strings.Index(exec.Query, " SET ")
+	end := strings.Index(exec.Query, " WHERE ")
+	if start < 0 || end < start {
+		return nil, false
+	}
+	for i, assignment := range strings.Split(exec.Query[start+len(" SET "):end], ",") {
+		if strings.HasPrefix(assignment, "`"+column+"`=") && i < len(exec.Args) {
+			return exec.Args[i], true
+		}
+	}
+	return nil, false
+}
+
+func TestSyncPascaKeepsAdminDeactivation(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	body := []byte(`{"data":[{"product_name":"PLN Pascabayar","category":"Pascabayar","brand":"PLN PASCABAYAR",` +
+		`"seller_name":"Toko Tagihan","admin":2500,"commission":1000,"buyer_sku_code":"PLNPASCA",` +
+		`"buyer_product_status":true,"seller_product_status":true,"desc":"-"}]}`)
+	removedAt := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		existing map[string]driver.Value
+		want     bool
+	}{
+		{name: "aktif tetap aktif", existing: map[string]driver.Value{"is_active": true}, want: true},
+		{name: "dinonaktifkan sync diaktifkan lagi", existing: map[string]driver.Value{"is_active": false, "sync_removed_at": removedAt}, want: true},
+		{name: "dinonaktifkan admin tetap nonaktif", existing: map[string]driver.Value{"is_active": false}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := digiflazzServer(t, body)
+			db, fake := testdb.Open(t)
+
+			row := testdb.Row{"id": int64(1), "buyer_sku_code": "PLNPASCA", "sync_removed_at": nil}
+			for column, value := range tt.existing {
+				row[column] = value
+			}
+			fake.SetRows("product_pascas", row)
+
+			if _, err := SyncPasca(context.Background(), db, client); err != nil {
+				t.Fatal(err)
+			}
+
+			var update *testdb.Exec
+			for _, exec := range fake.Execs("UPDATE `product_pascas`") {
+				if !strings.Contains(exec.Query, "NOT IN") {
+					update = &exec
+				}
+			}
+			if update == nil {
+				t.Fatal("produk pasca tidak di-update")
+			}
+			if got, _ := setValue(*update, "is_active"); got != tt.want {
+				t.Errorf("is_active = %v, want %v", got, tt.want)
+			}
+			if got, ok := setValue(*update, "sync_removed_at"); !ok || got != nil {
+				t.Errorf("sync_removed_at = %v, want NULL", got)
+			}
+		})
+	}
+
+	t.Run("SKU hilang ditandai sync_removed_at", func(t *testing.T) {
+		client := digiflazzServer(t, body)
+		db, fake := testdb.Open(t)
+
+		if _, err := SyncPasca(context.Background(), db, client); err != nil {
+			t.Fatal(err)
+		}
+
+		var deactivate []testdb.Exec
+		for _, exec := range fake.Execs("UPDATE `product_pascas`") {
+			if strings.Contains(exec.Query, "NOT IN") {
+				deactivate = append(deactivate, exec)
+			}
+		}
+		if len(deactivate) != 1 {
+			t.Fatalf("update nonaktif = %d, want 1", len(deactivate))
+		}
+		if got, ok := setValue(deactivate[0], "sync_removed_at"); !ok || got == nil {
+			t.Errorf("sync_removed_at = %v, want terisi", got)
+		}
+	})
+}
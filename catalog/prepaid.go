@@ -0,0 +1,304 @@
+// catalog/prepaid.go — sinkronisasi price list prabayar Digiflazz ke products
+package catalog
+
+import (
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"api-arveshop-go/pricing"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+
+	KindPrepaid = "prepaid"
+
+	ChangeNew     = "new"
+	ChangePrice   = "price"
+	ChangeStatus  = "status"
+	ChangeRemoved = "removed"
+
+	// Batas jumlah perubahan yang disimpan di report
+	maxReportChanges = 1000
+
+	// Jumlah baris per statement INSERT ... ON DUPLICATE KEY UPDATE
+	upsertBatchSize = 500
+)
+
+// Kolom yang diperbarui jika buyer_sku_code sudah ada (created_at tidak ikut).
+// is_active tidak ikut supaya produk yang dinonaktifkan admin tetap nonaktif.
+var upsertColumns = []string{
+	"product_name", "slug", "category", "brand", "type", "product_type", "seller_name",
+	"price", "selling_price", "buyer_product_status", "seller_product_status",
+	"unlimited_stock", "multi", "stock", "start_cut_off", "end_cut_off", "description",
+	"last_sync_at", "updated_at",
+}
+
+// upsertClause membuat INSERT ... ON DUPLICATE KEY UPDATE pada buyer_sku_code.
+// Produk yang dinonaktifkan sync (sync_removed_at terisi) diaktifkan lagi saat
+// muncul kembali di price list. MySQL menjalankan assignment berurutan, jadi
+// is_active harus dihitung sebelum sync_removed_at dikosongkan.
+func upsertClause() clause.OnConflict {
+	updates := clause.AssignmentColumns(upsertColumns)
+	updates = append(updates,
+		clause.Assignment{Column: clause.Column{Name: "is_active"}, Value: gorm.Expr("IF(sync_removed_at IS NULL, is_active, TRUE)")},
+		clause.Assignment{Column: clause.Column{Name: "sync_removed_at"}, Value: gorm.Expr("NULL")},
+	)
+	return clause.OnConflict{
+		Columns:   []clause.Column{{Name: "buyer_sku_code"}},
+		DoUpdates: updates,
+	}
+}
+
+// Change adalah satu perubahan produk dalam satu kali sync
+type Change struct {
+	SKU         string `json:"sku"`
+	ProductName string `json:"product_name"`
+	Type        string `json:"type"`
+
+	// Diisi untuk perubahan harga
+	OldPrice        int64 `json:"old_price,omitempty"`
+	NewPrice        int64 `json:"new_price,omitempty"`
+	OldSellingPrice int64 `json:"old_selling_price,omitempty"`
+	NewSellingPrice int64 `json:"new_selling_price,omitempty"`
+
+	// Diisi untuk perubahan status
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// SyncPrepaid mengambil price list prabayar, menghitung diff terhadap database
+// (produk baru, harga berubah, status berubah, produk hilang), menerapkan
+// perubahan dan menyimpan hasilnya sebagai models.SyncReport
+func SyncPrepaid(ctx context.Context, db *gorm.DB, client *digiflazz.Client, trigger string) (*models.SyncReport, error) {
+	report := &models.SyncReport{
+		Kind:      KindPrepaid,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+	}
+
+	changes, err := syncPrepaid(ctx, db, client, report)
+	if err != nil {
+		msg := err.Error()
+		report.Error = &msg
+	}
+
+	if len(changes) > maxReportChanges {
+		changes = changes[:maxReportChanges]
+	}
+	report.Changes, _ = json.Marshal(changes)
+	report.FinishedAt = time.Now()
+	report.DurationMs = report.FinishedAt.Sub(report.StartedAt).Milliseconds()
+
+	if saveErr := db.WithContext(ctx).Create(report).Error; saveErr != nil {
+		slog.Error("Gagal menyimpan sync report", "err", saveErr)
+	}
+
+	slog.Info("🔄 Sync produk prabayar selesai",
+		"trigger", trigger,
+		"total", report.Total,
+		"created", report.Created,
+		"price_changed", report.PriceChanged,
+		"status_changed", report.StatusChanged,
+		"removed", report.Removed,
+		"fetch_ms", report.FetchMs,
+		"write_ms", report.WriteMs,
+		"duration_ms", report.DurationMs,
+	)
+	return report, err
+}
+
+func syncPrepaid(ctx context.Context, db *gorm.DB, client *digiflazz.Client, report *models.SyncReport) ([]Change, error) {
+	fetchStart := time.Now()
+	products, err := client.PriceListPrepaid(ctx)
+	report.FetchMs = time.Since(fetchStart).Milliseconds()
+	if err != nil {
+		return nil, err
+	}
+	report.Total = len(products)
+
+	// Satu SELECT untuk semua produk, diff dihitung di memory
+	var existingProducts []models.Product
+	err = db.WithContext(ctx).
+		Select("id", "buyer_sku_code", "product_name", "product_type", "price", "selling_price", "buyer_product_status", "seller_product_status", "is_active", "sync_removed_at").
+		Find(&existingProducts).Error
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.Product, len(existingProducts))
+	for i := range existingProducts {
+		existing[existingProducts[i].BuyerSkuCode] = &existingProducts[i]
+	}
+
+	// Rule harga jual, tanpa rule harga jual = harga modal
+	engine, err := pricing.Load(ctx, db)
+	if err != nil {
+		slog.Error("Gagal memuat pricing rule", "err", err)
+		engine = pricing.NewEngine(nil)
+	}
+
+	var changes []Change
+	seen := make(map[string]bool, len(products))
+	now := time.Now()
+	rows := make([]models.Product, 0, len(products))
+
+	for _, item := range products {
+		if item.BuyerSkuCode == "" || item.ProductName == "" || seen[item.BuyerSkuCode] {
+			continue
+		}
+		seen[item.BuyerSkuCode] = true
+
+		product := productFromPriceList(item, engine, now)
+		rows = append(rows, product)
+
+		current, ok := existing[item.BuyerSkuCode]
+		if !ok {
+			report.Created++
+			changes = append(changes, Change{SKU: product.BuyerSkuCode, ProductName: product.ProductName, Type: ChangeNew})
+			continue
+		}
+
+		// Produk yang dinonaktifkan admin tidak diaktifkan lagi oleh sync
+		if !current.IsActive && current.SyncRemovedAt == nil {
+			product.IsActive = false
+		}
+
+		diff := diffProduct(current, &product)
+		if len(diff) == 0 {
+			report.Unchanged++
+			continue
+		}
+		for _, change := range diff {
+			switch change.Type {
+			case ChangePrice:
+				report.PriceChanged++
+			case ChangeStatus:
+				report.StatusChanged++
+			}
+		}
+		changes = append(changes, diff...)
+	}
+
+	// Price list kosong kemungkinan gangguan di Digiflazz, jangan nonaktifkan semua produk
+	var removedIDs []uint
+	if len(seen) > 0 {
+		for sku, product := range existing {
+			if seen[sku] || !product.IsActive || product.ProductType != KindPrepaid {
+				continue
+			}
+			removedIDs = append(removedIDs, product.ID)
+			changes = append(changes, Change{SKU: sku, ProductName: product.ProductName, Type: ChangeRemoved})
+		}
+	}
+
+	writeStart := time.Now()
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(rows) > 0 {
+			// INSERT ... ON DUPLICATE KEY UPDATE pada unique index buyer_sku_code
+			err := tx.Clauses(upsertClause()).CreateInBatches(&rows, upsertBatchSize).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(removedIDs) > 0 {
+			return tx.Model(&models.Product{}).
+				Where("id IN ?", removedIDs).
+				Updates(map[string]interface{}{"is_active": false, "sync_removed_at": &now, "updated_at": now}).Error
+		}
+		return nil
+	})
+	report.WriteMs = time.Since(writeStart).Milliseconds()
+	if err != nil {
+		return changes, err
+	}
+
+	report.Removed = len(removedIDs)
+	return changes, nil
+}
+
+func productFromPriceList(item digiflazz.PrepaidProduct, engine *pricing.Engine, now time.Time) models.Product {
+	price := item.Price.Int64()
+	sellingPrice, _ := engine.SellingPrice(item.Category, item.Brand, item.BuyerSkuCode, price)
+
+	return models.Product{
+		ProductName:         item.ProductName,
+		Slug:                slugify(item.Brand),
+		Category:            item.Category,
+		Brand:               item.Brand,
+		Type:                item.Type,
+		ProductType:         KindPrepaid,
+		SellerName:          item.SellerName,
+		Price:               price,
+		SellingPrice:        sellingPrice,
+		BuyerSkuCode:        item.BuyerSkuCode,
+		BuyerProductStatus:  item.BuyerProductStatus,
+		SellerProductStatus: item.SellerProductStatus,
+		UnlimitedStock:      item.UnlimitedStock,
+		Multi:               item.Multi,
+		Stock:               item.Stock.String(),
+		StartCutOff:         item.StartCutOff.String(),
+		EndCutOff:           item.EndCutOff.String(),
+		Description:         item.Desc,
+		IsActive:            true,
+		LastSyncAt:          &now,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+}
+
+// diffProduct membandingkan produk di database dengan data price list
+func diffProduct(current, incoming *models.Product) []Change {
+	var changes []Change
+
+	if current.Price != incoming.Price || current.SellingPrice != incoming.SellingPrice {
+		changes = append(changes, Change{
+			SKU:             current.BuyerSkuCode,
+			ProductName:     incoming.ProductName,
+			Type:            ChangePrice,
+			OldPrice:        current.Price,
+			NewPrice:        incoming.Price,
+			OldSellingPrice: current.SellingPrice,
+			NewSellingPrice: incoming.SellingPrice,
+		})
+	}
+
+	currentStatus := statusLabel(current.IsActive, current.BuyerProductStatus, current.SellerProductStatus)
+	incomingStatus := statusLabel(incoming.IsActive, incoming.BuyerProductStatus, incoming.SellerProductStatus)
+	if currentStatus != incomingStatus {
+		changes = append(changes, Change{
+			SKU:         current.BuyerSkuCode,
+			ProductName: incoming.ProductName,
+			Type:        ChangeStatus,
+			From:        currentStatus,
+			To:          incomingStatus,
+		})
+	}
+
+	return changes
+}
+
+func statusLabel(active, buyer, seller bool) string {
+	switch {
+	case !active:
+		return "inactive"
+	case buyer && seller:
+		return "available"
+	case !seller:
+		return "seller_closed"
+	default:
+		return "buyer_closed"
+	}
+}
+
+func slugify(text string) string {
+	return strings.ReplaceAll(strings.ToLower(text), " ", "-")
+}
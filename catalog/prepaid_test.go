@@ -0,0 +1,183 @@
+package catalog
+
+import (
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"api-arveshop-go/pricing"
+	"api-arveshop-go/testdb"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jumlah SKU di benchmark, kira-kira sebesar price list prabayar Digiflazz
+const benchmarkSKUs = 2000
+
+// Latensi satu round trip ke MySQL yang disimulasikan
+const roundTrip = 200 * time.Microsecond
+
+// ─── Digiflazz stand-in ───────────────────────────────────────────────────────
+
+// fixturePriceList memperbanyak testdata/pricelist_prepaid.json sampai n SKU
+func fixturePriceList(tb testing.TB, n int) []byte {
+	tb.Helper()
+	raw, err := os.ReadFile("testdata/pricelist_prepaid.json")
+	if err != nil {
+		tb.Fatalf("read fixture: %v", err)
+	}
+	var fixture struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &fixture); err != nil {
+		tb.Fatalf("decode fixture: %v", err)
+	}
+
+	items := make([]map[string]any, 0, n)
+	for i := 0; len(items) < n; i++ {
+		for _, item := range fixture.Data {
+			if len(items) == n {
+				break
+			}
+			clone := make(map[string]any, len(item))
+			for k, v := range item {
+				clone[k] = v
+			}
+			clone["buyer_sku_code"] = fmt.Sprintf("%s-%d", item["buyer_sku_code"], i)
+			items = append(items, clone)
+		}
+	}
+
+	body, err := json.Marshal(map[string]any{"data": items})
+	if err != nil {
+		tb.Fatalf("encode price list: %v", err)
+	}
+	return body
+}
+
+func digiflazzServer(tb testing.TB, body []byte) *digiflazz.Client {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	tb.Cleanup(server.Close)
+	return digiflazz.New(digiflazz.Config{Username: "test", APIKey: "test", BaseURL: server.URL})
+}
+
+// ─── Upsert ───────────────────────────────────────────────────────────────────
+
+func TestUpsertKeepsAdminDeactivation(t *testing.T) {
+	db := testdb.DryRun(t)
+
+	rows := []models.Product{{BuyerSkuCode: "TSEL5", ProductName: "Telkomsel 5000", IsActive: true}}
+	tx := db.Clauses(upsertClause()).Create(&rows)
+	if tx.Error != nil {
+		t.Fatal(tx.Error)
+	}
+	sql := tx.Statement.SQL.String()
+
+	if strings.Contains(sql, "`is_active`=VALUES(`is_active`)") {
+		t.Errorf("upsert menimpa is_active dari price list:\n%s", sql)
+	}
+	// is_active dihitung sebelum sync_removed_at dikosongkan
+	reactivate := strings.Index(sql, "`is_active`=IF(sync_removed_at IS NULL, is_active, TRUE)")
+	clear := strings.Index(sql, "`sync_removed_at`=NULL")
+	if reactivate < 0 || clear < 0 || reactivate > clear {
+		t.Errorf("urutan assignment is_active / sync_removed_at salah:\n%s", sql)
+	}
+}
+
+func TestSyncPrepaidKeepsClosedStatus(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	body := []byte(`{"data":[{"product_name":"Telkomsel 5000","category":"Pulsa","brand":"TELKOMSEL","type":"Umum",` +
+		`"seller_name":"Toko Pulsa","price":5150,"buyer_sku_code":"TSEL5","buyer_product_status":true,` +
+		`"seller_product_status":false,"unlimited_stock":true,"stock":0,"multi":true,` +
+		`"start_cut_off":"0:00","end_cut_off":"0:00","desc":"-"}]}`)
+	client := digiflazzServer(t, body)
+	db, fake := testdb.Open(t)
+
+	if _, err := SyncPrepaid(context.Background(), db, client, TriggerManual); err != nil {
+		t.Fatal(err)
+	}
+
+	inserts := fake.Execs("INSERT INTO `products`")
+	if len(inserts) != 1 {
+		t.Fatalf("insert products = %d, want 1", len(inserts))
+	}
+	row := inserts[0].Row()
+	for column, want := range map[string]bool{"buyer_product_status": true, "seller_product_status": false} {
+		if got, ok := row[column].(bool); !ok || got != want {
+			t.Errorf("%s = %v, want %v", column, row[column], want)
+		}
+	}
+}
+
+// ─── Per-row path ─────────────────────────────────────────────────────────────
+
+// syncPrepaidPerRow adalah cara lama GetProducts: satu SELECT lalu satu
+// UPDATE atau INSERT untuk setiap SKU
+func syncPrepaidPerRow(ctx context.Context, db *gorm.DB, client *digiflazz.Client) error {
+	products, err := client.PriceListPrepaid(ctx)
+	if err != nil {
+		return err
+	}
+
+	engine := pricing.NewEngine(nil)
+	now := time.Now()
+	for _, item := range products {
+		if item.BuyerSkuCode == "" || item.ProductName == "" {
+			continue
+		}
+		product := productFromPriceList(item, engine, now)
+
+		var existing models.Product
+		if err := db.WithContext(ctx).Where("buyer_sku_code = ?", item.BuyerSkuCode).First(&existing).Error; err == nil {
+			db.WithContext(ctx).Model(&existing).Updates(&product)
+			continue
+		}
+		db.WithContext(ctx).Create(&product)
+	}
+	return nil
+}
+
+// ─── Benchmarks ───────────────────────────────────────────────────────────────
+
+func BenchmarkSyncPrepaid(b *testing.B) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := context.Background()
+	client := digiflazzServer(b, fixturePriceList(b, benchmarkSKUs))
+	db, fake := testdb.Open(b)
+	fake.SetLatency(roundTrip)
+
+	b.Run("batched", func(b *testing.B) {
+		fake.ResetRoundTrips()
+		for i := 0; i < b.N; i++ {
+			if _, err := SyncPrepaid(ctx, db, client, TriggerManual); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(fake.RoundTrips())/float64(b.N), "roundtrips/op")
+	})
+
+	b.Run("per-row", func(b *testing.B) {
+		fake.ResetRoundTrips()
+		for i := 0; i < b.N; i++ {
+			if err := syncPrepaidPerRow(ctx, db, client); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(fake.RoundTrips())/float64(b.N), "roundtrips/op")
+	})
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"api-arveshop-go/balance"
+	"api-arveshop-go/config"
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"api-arveshop-go/requests"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBalance menampilkan saldo lokal dan snapshot saldo Digiflazz terakhir
+func GetBalance(c *gin.Context) {
+	var profil models.ProfilAplikasi
+	if err := config.DB.First(&profil).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Profil aplikasi tidak ditemukan"})
+		return
+	}
+
+	var snapshot *models.BalanceSnapshot
+	var latest models.BalanceSnapshot
+	if err := config.DB.Order("created_at DESC").First(&latest).Error; err == nil {
+		snapshot = &latest
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data": gin.H{
+			"local_saldo":     profil.Saldo,
+			"latest_snapshot": snapshot,
+			"threshold":       balance.DriftThreshold(),
+		},
+	})
+}
+
+// CheckBalance mengambil saldo Digiflazz sekarang dan menyimpan snapshot
+func CheckBalance(c *gin.Context) {
+	snapshot, err := balance.Check(c.Request.Context(), config.DB, digiflazz.NewFromEnv(), balance.SourceManual)
+	if err != nil {
+		log.Printf("Gagal cek saldo Digiflazz: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"message": "Gagal cek saldo Digiflazz", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Saldo berhasil dicek",
+		"data":    snapshot,
+	})
+}
+
+// CorrectBalance menyamakan saldo lokal dengan saldo Digiflazz
+func CorrectBalance(c *gin.Context) {
+	var req requests.BalanceCorrectionRequest
+	c.ShouldBindJSON(&req)
+
+	correction, err := balance.Correct(c.Request.Context(), config.DB, digiflazz.NewFromEnv(), req.Note)
+	if err != nil {
+		log.Printf("Gagal koreksi saldo: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"message": "Gagal koreksi saldo", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Saldo berhasil dikoreksi",
+		"data":    correction,
+	})
+}
+
+func GetBalanceSnapshots(c *gin.Context) {
+	var snapshots []models.BalanceSnapshot
+
+	query := config.DB.Order("created_at DESC").Limit(200)
+	if c.Query("flagged") == "true" {
+		query = query.Where("flagged = ?", true)
+	}
+
+	if err := query.Find(&snapshots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    snapshots,
+	})
+}
+
+func GetBalanceCorrections(c *gin.Context) {
+	var corrections []models.BalanceCorrection
+
+	if err := config.DB.Order("created_at DESC").Limit(200).Find(&corrections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    corrections,
+	})
+}
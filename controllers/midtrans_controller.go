@@ -2,16 +2,20 @@ package controllers
 
 import (
 	"api-arveshop-go/config"
+	"api-arveshop-go/digiflazz"
 	"api-arveshop-go/models"
+	"api-arveshop-go/orderid"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/seller"
+	"api-arveshop-go/txstate"
 	"api-arveshop-go/websocket"
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"math/rand"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,183 +25,213 @@ import (
 
 type CreateTransactionRequest struct {
 	ID              uint    `json:"id" binding:"required"`
-	ProductName     string  `json:"product_name" binding:"required"`
+	ProductName     string  `json:"product_name"`
 	ProductType     string  `json:"product_type"`
 	BuyerSkuCode    string  `json:"buyer_sku_code" binding:"required"`
 	CustomerNo      string  `json:"customer_no" binding:"required"`
+	// Harga dari client hanya dicocokkan dengan harga di database, tidak dipakai untuk charge
 	SellingPrice    float64 `json:"selling_price" binding:"required"`
 	Fee             float64 `json:"fee"`
-	PurchasePrice   float64 `json:"purchase_price"`
 	PaymentMethodName string `json:"payment_method_name" binding:"required"` // ✅ KONSISTEN
 	WaPembeli       string  `json:"wa_pembeli" binding:"required"`
+	// Pembeli sudah melihat peringatan cutoff dan setuju pesanan diproses nanti
+	AcceptCutoff    bool    `json:"accept_cutoff"`
 }
 
-func CreateTransaction(c *gin.Context) {
-	var req CreateTransactionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
-
-	// ===============================
-	// INIT
-	// ===============================
-
-	rand.Seed(time.Now().UnixNano())
+var (
+	errProductNotFound       = errors.New("produk tidak ditemukan atau tidak aktif")
+	errPaymentMethodNotFound = errors.New("metode pembayaran tidak ditemukan atau tidak aktif")
+)
 
-	// ✅ VALIDASI DECIMAL
-	sellingPrice := decimal.NewFromFloat(req.SellingPrice)
-	fee := decimal.NewFromFloat(req.Fee)
-	purchasePrice := decimal.NewFromFloat(req.PurchasePrice)
-	
-	// Hitung gross amount
-	grossAmount := sellingPrice.Add(fee)
+// priceMismatchError dikembalikan jika harga dari client berbeda dengan harga server
+type priceMismatchError struct {
+	Field    string
+	Client   decimal.Decimal
+	Expected decimal.Decimal
+}
 
-	orderID := fmt.Sprintf("ORD-%s-%d",
-		time.Now().Format("20060102150405"),
-		rand.Intn(9000)+1000,
-	)
+func (e *priceMismatchError) Error() string {
+	return fmt.Sprintf("%s tidak sesuai: client %s, server %s", e.Field, e.Client, e.Expected)
+}
 
-	// ===============================
-	// ITEM DETAILS
-	// ===============================
+// transactionQuote adalah harga final yang dihitung server untuk satu transaksi
+type transactionQuote struct {
+	Product       models.Product
+	// Seller yang dipakai untuk mengirim, bisa berbeda dari produk pilihan pembeli
+	Fulfilment    models.Product
+	// Policy & skor seller terpilih, disimpan di transaksi untuk audit
+	SellerPolicy  string
+	SellerScore   *float64
+	PaymentMethod models.PaymentMethod
+	SellingPrice  decimal.Decimal
+	PurchasePrice decimal.Decimal
+	Fee           decimal.Decimal
+}
 
-	itemDetails := []map[string]interface{}{
-		{
-			"id":       fmt.Sprintf("%d", req.ID),
-			"price":    int(sellingPrice.IntPart()),
-			"quantity": 1,
-			"name":     req.ProductName,
-		},
+// resolveTransactionQuote mengambil harga jual & harga beli dari models.Product
+// dan menghitung fee dari models.PaymentMethod. Harga dari client hanya divalidasi.
+func resolveTransactionQuote(req CreateTransactionRequest) (*transactionQuote, error) {
+	var product models.Product
+	err := config.DB.
+		Where("buyer_sku_code = ?", req.BuyerSkuCode).
+		Where("is_active = ? AND buyer_product_status = ? AND seller_product_status = ?", true, true, true).
+		First(&product).Error
+	if err != nil {
+		return nil, errProductNotFound
 	}
-
-	if fee.GreaterThan(decimal.Zero) {
-		itemDetails = append(itemDetails, map[string]interface{}{
-			"id":       "fee",
-			"price":    int(fee.IntPart()),
-			"quantity": 1,
-			"name":     "Biaya Admin",
-		})
+	if req.ID != product.ID {
+		return nil, errProductNotFound
 	}
 
-	transactionData := map[string]interface{}{
-		"transaction_details": map[string]interface{}{
-			"order_id":     orderID,
-			"gross_amount": int(grossAmount.IntPart()),
-		},
-		"item_details": itemDetails,
+	var paymentMethod models.PaymentMethod
+	err = config.DB.
+		Where("LOWER(name) = ? AND is_active = ?", strings.ToLower(req.PaymentMethodName), true).
+		First(&paymentMethod).Error
+	if err != nil {
+		return nil, errPaymentMethodNotFound
 	}
 
-	paymentType := ""
-	paymentMethodName := req.PaymentMethodName
-
-	// ===============================
-	// PAYMENT TYPE LOGIC
-	// ===============================
+	quote := &transactionQuote{
+		Product:       product,
+		PaymentMethod: paymentMethod,
+		SellingPrice:  decimal.NewFromInt(product.SellingPrice),
+		PurchasePrice: decimal.NewFromInt(product.Price),
+	}
+	quote.Fee = paymentMethod.CalculateFee(quote.SellingPrice)
 
-	switch req.PaymentMethodName {
-	case "qris":
-		paymentType = "qris"
-		transactionData["payment_type"] = "qris"
-		transactionData["qris"] = map[string]interface{}{
-			"acquirer": "gopay",
-		}
+	clientPrice := decimal.NewFromFloat(req.SellingPrice)
+	if !clientPrice.Equal(quote.SellingPrice) {
+		return nil, &priceMismatchError{Field: "selling_price", Client: clientPrice, Expected: quote.SellingPrice}
+	}
 
-	case "gopay":
-		paymentType = "gopay"
-		transactionData["payment_type"] = "gopay"
-		transactionData["gopay"] = map[string]interface{}{
-			"enable_callback": true,
-			"callback_url":    os.Getenv("APP_URL") + "/api/callback/midtrans",
-		}
+	clientFee := decimal.NewFromFloat(req.Fee).Ceil()
+	if !clientFee.Equal(quote.Fee) {
+		return nil, &priceMismatchError{Field: "fee", Client: clientFee, Expected: quote.Fee}
+	}
 
-	case "shopeepay":
-		paymentType = "shopeepay"
-		transactionData["payment_type"] = "shopeepay"
-		transactionData["shopeepay"] = map[string]interface{}{
-			"callback_url": os.Getenv("APP_URL") + "/api/callback/midtrans",
+	// Pilih seller setara sesuai SELLER_POLICY, harga jual ke pembeli tetap
+	quote.Fulfilment = product
+	policy := seller.PolicyFromEnv()
+	selected, err := seller.Select(context.Background(), config.DB, &product, policy)
+	if err != nil {
+		log.Printf("Gagal memilih seller untuk %s: %v", product.BuyerSkuCode, err)
+	} else {
+		quote.SellerPolicy = policy.Name
+		quote.SellerScore = &selected.Score
+		log.Printf("🏷️ Seller %s (%s) dipilih untuk %s, policy=%s score=%.4f reliability=%.4f",
+			selected.Product.SellerName, selected.Product.BuyerSkuCode, product.BuyerSkuCode,
+			policy.Name, selected.Score, selected.Reliability)
+		if selected.Product.ID != product.ID {
+			quote.Fulfilment = selected.Product
+			quote.PurchasePrice = decimal.NewFromInt(selected.Product.Price)
 		}
+	}
 
-	case "bca", "bni", "bri", "permata", "mandiri", "cimb":
-		paymentType = "bank_transfer"
-		transactionData["payment_type"] = "bank_transfer"
-		transactionData["bank_transfer"] = map[string]interface{}{
-			"bank": req.PaymentMethodName,
-		}
+	return quote, nil
+}
 
-	default:
+func CreateTransaction(c *gin.Context) {
+	var req CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid payment method",
+			"error": err.Error(),
 		})
 		return
 	}
 
 	// ===============================
-	// CALL MIDTRANS
+	// INIT
 	// ===============================
 
-	jsonData, err := json.Marshal(transactionData)
+	// ✅ HARGA DARI SERVER, BUKAN DARI CLIENT
+	quote, err := resolveTransactionQuote(req)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to encode JSON"})
+		var mismatch *priceMismatchError
+		switch {
+		case errors.As(err, &mismatch):
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    "Harga telah berubah, silakan muat ulang halaman",
+				"field":    mismatch.Field,
+				"expected": mismatch.Expected,
+			})
+		case errors.Is(err, errProductNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	// Log request untuk debugging
-	fmt.Printf("Midtrans Request: %s\n", string(jsonData))
-
-	midtransURL := "https://api.sandbox.midtrans.com/v2/charge"
-	if os.Getenv("MIDTRANS_ENV") == "production" {
-		midtransURL = "https://api.midtrans.com/v2/charge"
-	}
-
-	httpReq, err := http.NewRequest("POST", midtransURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed create request"})
+	// Peringatan cutoff harus dikonfirmasi sebelum pembeli membayar
+	cutoff := cutoffNotice(&quote.Fulfilment)
+	if cutoff != nil && !req.AcceptCutoff {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "Produk sedang cutoff, kirim ulang dengan accept_cutoff: true untuk melanjutkan",
+			"cutoff": cutoff,
+		})
 		return
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json")
-	httpReq.SetBasicAuth(os.Getenv("MIDTRANS_SERVER_KEY"), "")
+	sellingPrice := quote.SellingPrice
+	fee := quote.Fee
+	purchasePrice := quote.PurchasePrice
+	
+	// Hitung gross amount
+	grossAmount := sellingPrice.Add(fee)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
+	// order_id dibuat & di-reserve sebelum memanggil Midtrans
+	orderID, err := orderid.Default.New(c.Request.Context(), c.GetHeader("X-Storefront"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed call Midtrans: " + err.Error()})
+		log.Printf("Failed to generate order_id: %v", err)
+		c.JSON(500, gin.H{"error": "Failed generate order ID"})
 		return
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	// ===============================
+	// ITEM DETAILS
+	// ===============================
 
-	// Log response untuk debugging
-	fmt.Printf("Midtrans Response (%d): %s\n", resp.StatusCode, string(body))
+	items := []payment.Item{
+		{
+			ID:       fmt.Sprintf("%d", quote.Product.ID),
+			Name:     quote.Product.ProductName,
+			Price:    sellingPrice,
+			Quantity: 1,
+		},
+	}
 
-	if resp.StatusCode != 200 && resp.StatusCode != 201 {
-		c.JSON(resp.StatusCode, gin.H{
-			"error":   "Midtrans error",
-			"message": string(body),
+	if fee.GreaterThan(decimal.Zero) {
+		items = append(items, payment.Item{
+			ID:       "fee",
+			Name:     "Biaya Admin",
+			Price:    fee,
+			Quantity: 1,
 		})
-		return
 	}
 
-	var responseData map[string]interface{}
-	if err := json.Unmarshal(body, &responseData); err != nil {
-		c.JSON(500, gin.H{"error": "Invalid Midtrans response"})
+	// ===============================
+	// CALL PAYMENT GATEWAY
+	// ===============================
+
+	charged, ok := chargePayment(c, paymentCharge{
+		OrderID:       orderID,
+		PaymentMethod: quote.PaymentMethod,
+		MethodName:    req.PaymentMethodName,
+		GrossAmount:   grossAmount,
+		Items:         items,
+		WaPembeli:     req.WaPembeli,
+	})
+	if !ok {
 		return
 	}
+	charge := charged.Result
+	gateway := charged.Gateway
+	expiredAt := charged.ExpiredAt
 
-	transactionID, _ := responseData["transaction_id"].(string)
-	statusMessage, _ := responseData["status_message"].(string)
-
-	urlOrVA := getPaymentURLOrVA(responseData)
-	deeplinkGopay := getDeeplinkGopay(responseData)
-
+	paymentMethodName := req.PaymentMethodName
 
 	// Konversi response ke JSON untuk disimpan di database
-	midtransResponseJSON, err := json.Marshal(responseData)
+	midtransResponseJSON, err := json.Marshal(charge.Response)
 	if err != nil {
 		log.Printf("Warning: Failed to marshal Midtrans response: %v", err)
 		midtransResponseJSON = []byte("{}")
@@ -208,22 +242,28 @@ func CreateTransaction(c *gin.Context) {
 	// ===============================
 
 	transaction := models.Transaction{
-		ProductID:         &req.ID,
-		ProductName:       stringPtr(req.ProductName),
-		ProductType:       stringPtr(req.ProductType),
+		ProductID:         &quote.Fulfilment.ID,
+		RequestedProductID: &quote.Product.ID,
+		ProductName:       stringPtr(quote.Product.ProductName),
+		ProductType:       stringPtr(quote.Product.ProductType),
 		CustomerNo:        req.CustomerNo,
-		BuyerSkuCode:      req.BuyerSkuCode,
+		BuyerSkuCode:      quote.Fulfilment.BuyerSkuCode,
+		SellerPolicy:      stringPtr(quote.SellerPolicy),
+		SellerScore:       quote.SellerScore,
 		OrderID:           orderID,
-		TransactionID:     stringPtr(transactionID),
+		IsTest:            digiflazz.TestingEnabled(),
+		TransactionID:     stringPtr(charge.TransactionID),
 		GrossAmount:       grossAmount,
 		SellingPrice:      sellingPrice,
 		PurchasePrice:     purchasePrice,
-		PaymentType:       stringPtr(paymentType),
+		PaymentGateway:    gateway.Name(),
+		PaymentType:       stringPtr(charge.PaymentType),
 		PaymentMethodName: stringPtr(paymentMethodName),
 		PaymentStatus:     "pending",
-		StatusMessage:     stringPtr(statusMessage),
-		URL:               stringPtr(urlOrVA),
-		DeeplinkGopay:     stringPtr(deeplinkGopay),
+		ExpiredAt:         &expiredAt,
+		StatusMessage:     stringPtr(charge.StatusMessage),
+		URL:               stringPtr(charge.PaymentURL),
+		DeeplinkGopay:     stringPtr(charge.Deeplink),
 		WaPembeli:         req.WaPembeli,
 		MidtransResponse:  datatypes.JSON(midtransResponseJSON),
 	}
@@ -240,70 +280,84 @@ func CreateTransaction(c *gin.Context) {
 		"message": "Payment created",
 		"data": gin.H{
 			"transaction":   transaction,
-			"payment_url":   urlOrVA,
-			"deeplink":      deeplinkGopay,
-			"midtrans_data": responseData,
+			"payment_url":   charge.PaymentURL,
+			"deeplink":      charge.Deeplink,
+			"midtrans_data": charge.Response,
+			"cutoff":        cutoff,
 		},
 	})
 }
 
-func stringPtr(s string) *string {
-	if s == "" {
-		return nil
-	}
-	return &s
+// paymentCharge adalah data untuk membuat charge di payment gateway
+type paymentCharge struct {
+	OrderID       string
+	PaymentMethod models.PaymentMethod
+	MethodName    string
+	GrossAmount   decimal.Decimal
+	Items         []payment.Item
+	WaPembeli     string
+	CustomerName  string
 }
 
-func getPaymentURLOrVA(data map[string]interface{}) string {
-	// QRIS / e-wallet
-	if actions, ok := data["actions"].([]interface{}); ok {
-		for _, a := range actions {
-			if action, ok := a.(map[string]interface{}); ok {
-				if name, ok := action["name"].(string); ok && name == "generate-qr-code" {
-					if url, ok := action["url"].(string); ok {
-						return url
-					}
-				}
-				// Fallback ke url pertama
-				if url, ok := action["url"].(string); ok {
-					return url
-				}
-			}
-		}
-	}
+type chargedPayment struct {
+	Gateway   payment.Gateway
+	Result    *payment.ChargeResult
+	ExpiredAt time.Time
+}
 
-	// VA
-	if vaNumbers, ok := data["va_numbers"].([]interface{}); ok {
-		if len(vaNumbers) > 0 {
-			if va, ok := vaNumbers[0].(map[string]interface{}); ok {
-				if number, ok := va["va_number"].(string); ok {
-					return number
-				}
-			}
-		}
+// chargePayment membuat charge di gateway sesuai metode pembayaran.
+// Jika gagal, response error sudah ditulis dan ok bernilai false.
+func chargePayment(c *gin.Context, pc paymentCharge) (*chargedPayment, bool) {
+	gateway, err := payment.Get(pc.PaymentMethod.Gateway)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return nil, false
 	}
 
-	// Redirect URL
-	if redirectURL, ok := data["redirect_url"].(string); ok {
-		return redirectURL
+	orderTime := time.Now()
+	expiry := payment.ExpiryFor(pc.PaymentMethod.Type)
+
+	charge, err := gateway.Charge(c.Request.Context(), payment.ChargeRequest{
+		OrderID:       pc.OrderID,
+		GrossAmount:   pc.GrossAmount,
+		Items:         pc.Items,
+		PaymentMethod: pc.MethodName,
+		GatewayCode:   pc.PaymentMethod.GatewayCode,
+		CustomerPhone: pc.WaPembeli,
+		CustomerName:  pc.CustomerName,
+		OrderTime:     orderTime,
+		Expiry:        expiry,
+	})
+	if err != nil {
+		var gatewayErr *payment.GatewayError
+		switch {
+		case errors.Is(err, payment.ErrUnsupportedMethod):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid payment method",
+			})
+		case errors.As(err, &gatewayErr):
+			c.JSON(gatewayErr.StatusCode, gin.H{
+				"error":   gatewayErr.Gateway + " error",
+				"message": gatewayErr.Body,
+			})
+		default:
+			c.JSON(500, gin.H{"error": "Failed call " + gateway.Name() + ": " + err.Error()})
+		}
+		return nil, false
 	}
 
-	return ""
+	return &chargedPayment{
+		Gateway:   gateway,
+		Result:    charge,
+		ExpiredAt: orderTime.Add(expiry),
+	}, true
 }
 
-func getDeeplinkGopay(data map[string]interface{}) string {
-	if actions, ok := data["actions"].([]interface{}); ok {
-		for _, a := range actions {
-			if action, ok := a.(map[string]interface{}); ok {
-				if name, ok := action["name"].(string); ok && name == "deeplink-redirect" {
-					if url, ok := action["url"].(string); ok {
-						return url
-					}
-				}
-			}
-		}
+func stringPtr(s string) *string {
+	if s == "" {
+		return nil
 	}
-	return ""
+	return &s
 }
 
 func GetStatusPayment(p *gin.Context) {
@@ -332,47 +386,83 @@ func GetStatusPayment(p *gin.Context) {
 	})
 }
 
-// WebSocket endpoint
-func WebSocketConnection(c *gin.Context) {
-	websocket.HandleWebSocket(c)
-}
+// CancelTransaction membatalkan transaksi yang belum dibayar atas permintaan pembeli
+func CancelTransaction(c *gin.Context) {
+	orderID := c.Param("order_id")
 
-// UpdatePaymentStatus - Contoh fungsi yang memicu WebSocket broadcast
-func UpdatePaymentStatus(c *gin.Context) {
-	var req struct {
-		OrderID        string `json:"order_id"`
-		PaymentStatus  string `json:"payment_status"`
-		DigiflazzStatus string `json:"digiflazz_status"`
+	var transaction models.Transaction
+	if err := config.DB.Where("order_id = ?", orderID).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "data tidak ditemukan"})
+		return
 	}
-	
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+	if transaction.PaymentStatus != payment.StatusPending {
+		c.JSON(http.StatusConflict, gin.H{
+			"message":        "Transaksi tidak dapat dibatalkan",
+			"payment_status": transaction.PaymentStatus,
+		})
 		return
 	}
-	
-	// Update database
-	var transaction models.Transaction
-	if err := config.DB.Where("order_id = ?", req.OrderID).First(&transaction).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+
+	gateway, err := payment.Get(transaction.PaymentGateway)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
-	
-	updates := map[string]interface{}{
-		"payment_status":   req.PaymentStatus,
-		"digiflazz_status": req.DigiflazzStatus,
-		"updated_at":       time.Now(),
+
+	ref := payment.Reference{OrderID: transaction.OrderID}
+	if transaction.TransactionID != nil {
+		ref.TransactionID = *transaction.TransactionID
 	}
-	
-	config.DB.Model(&transaction).Updates(updates)
-	
-	// Broadcast update via WebSocket
-	websocket.BroadcastOrderStatus(req.OrderID)
-	
+
+	if _, err := gateway.Cancel(c.Request.Context(), ref); err != nil {
+		if errors.Is(err, payment.ErrNotSupported) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"message": "Metode pembayaran ini tidak mendukung pembatalan, transaksi akan expired otomatis",
+			})
+			return
+		}
+		log.Printf("Failed to cancel %s at %s: %v", orderID, gateway.Name(), err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"message": "Gagal membatalkan transaksi di payment gateway",
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	statusMsg := "Dibatalkan oleh pembeli"
+	_, err = txstate.Apply(c.Request.Context(), config.DB, &transaction, txstate.Change{
+		Payment:    payment.StatusCancelled,
+		Fulfilment: txstate.FulfilmentCancelled,
+		Source:     txstate.SourceCustomer,
+		Note:       statusMsg,
+		Updates:    map[string]interface{}{"status_message": &statusMsg},
+	})
+	if errors.Is(err, txstate.ErrIllegalTransition) {
+		c.JSON(http.StatusConflict, gin.H{"message": "Status transaksi sudah berubah"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengupdate transaksi"})
+		return
+	}
+
+	websocket.BroadcastOrderStatus(orderID)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Status updated and broadcasted",
+		"message": "Transaksi dibatalkan",
+		"data": gin.H{
+			"order_id":       orderID,
+			"payment_status": payment.StatusCancelled,
+		},
 	})
 }
 
+// WebSocket endpoint
+func WebSocketConnection(c *gin.Context) {
+	websocket.HandleWebSocket(c)
+}
+
 // Webhook handler yang memicu WebSocket
 // func HandleMidtransWebhook(c *gin.Context) {
 // 	// ... existing webhook code ...
@@ -3,6 +3,7 @@ package controllers
 import (
 	"api-arveshop-go/config"
 	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
 	"api-arveshop-go/requests"
 	"api-arveshop-go/utils"
 	"log"
@@ -59,6 +60,14 @@ func CreatePaymentMethod(c *gin.Context) {
 		return
 	}
 
+	if _, err := payment.Get(req.Gateway); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Gateway tidak valid",
+			"error":   err.Error(),
+		})
+		return
+	}
+
 	var logoURL, logoPublicId string
 
 	// Handle logo upload
@@ -89,6 +98,8 @@ func CreatePaymentMethod(c *gin.Context) {
 		NominalFee:    req.NominalFee,
 		PercentaseFee: req.PercentaseFee,
 		Type:          req.Type,
+		Gateway:       gatewayOrDefault(req.Gateway),
+		GatewayCode:   req.GatewayCode,
 		IsActive:      req.IsActive,
 		Logo:          logoURL,
 		LogoPublicID:  logoPublicId,
@@ -130,6 +141,14 @@ func UpdatePaymentMethod(p *gin.Context) {
         log.Printf("Logo file: %s, size: %d", req.Logo.Filename, req.Logo.Size)
     }
 
+    if _, err := payment.Get(req.Gateway); err != nil {
+        p.JSON(http.StatusBadRequest, gin.H{
+            "message": "Gateway tidak valid",
+            "error":   err.Error(),
+        })
+        return
+    }
+
     // Cari data payment method yang akan diupdate
     var paymentMethod models.PaymentMethod
     err := config.DB.Where("id = ?", id).First(&paymentMethod).Error
@@ -144,6 +163,8 @@ func UpdatePaymentMethod(p *gin.Context) {
     paymentMethod.PercentaseFee = req.PercentaseFee
     paymentMethod.NominalFee = req.NominalFee
     paymentMethod.Type = req.Type
+    paymentMethod.Gateway = gatewayOrDefault(req.Gateway)
+    paymentMethod.GatewayCode = req.GatewayCode
     paymentMethod.IsActive = req.IsActive
 
     // Handle logo
@@ -222,7 +243,7 @@ func DeletePaymentMethod(p *gin.Context) {
 		return
 	}
 
-	if paymentMethod.LogoPublicID != "" && paymentMethod.LogoPublicID != "" {
+	if paymentMethod.LogoPublicID != "" {
         if err := utils.DeleteFile(paymentMethod.LogoPublicID); err != nil {
             log.Printf("Warning: Failed to delete logo from Cloudinary: %v", err)
         }
@@ -245,4 +266,12 @@ func DeletePaymentMethod(p *gin.Context) {
     })
 
 
-}
\ No newline at end of file
+}
+
+// gatewayOrDefault mengisi gateway kosong dengan payment.DefaultGateway
+func gatewayOrDefault(gateway string) string {
+	if gateway == "" {
+		return payment.DefaultGateway
+	}
+	return gateway
+}
@@ -0,0 +1,319 @@
+package controllers
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/digiflazz"
+	"api-arveshop-go/models"
+	"api-arveshop-go/orderid"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/requests"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// Hasil inquiry disimpan sementara, checkout harus dilakukan sebelum TTL habis
+const postpaidInquiryTTL = 30 * time.Minute
+
+// postpaidInquiry adalah tagihan hasil inq-pasca. InquiryID dipakai sebagai
+// ref_id Digiflazz sekaligus order_id, karena pay-pasca wajib memakai ref_id yang sama.
+type postpaidInquiry struct {
+	InquiryID    string          `json:"inquiry_id"`
+	BuyerSkuCode string          `json:"buyer_sku_code"`
+	ProductName  string          `json:"product_name"`
+	CustomerNo   string          `json:"customer_no"`
+	CustomerName string          `json:"customer_name"`
+	Period       string          `json:"period"`
+	BillAmount   int64           `json:"bill_amount"`
+	AdminFee     int64           `json:"admin_fee"`
+	Total        int64           `json:"total"`
+	Price        int64           `json:"price"` // harga beli ke Digiflazz, tidak dikirim ke client
+	Desc         json.RawMessage `json:"desc,omitempty"`
+}
+
+// view adalah data tagihan yang ditampilkan ke customer
+func (i postpaidInquiry) view() gin.H {
+	return gin.H{
+		"inquiry_id":     i.InquiryID,
+		"buyer_sku_code": i.BuyerSkuCode,
+		"product_name":   i.ProductName,
+		"customer_no":    i.CustomerNo,
+		"customer_name":  i.CustomerName,
+		"period":         i.Period,
+		"bill_amount":    i.BillAmount,
+		"admin_fee":      i.AdminFee,
+		"total":          i.Total,
+		"desc":           i.Desc,
+	}
+}
+
+func postpaidInquiryKey(inquiryID string) string {
+	return "postpaid_inquiry:" + inquiryID
+}
+
+// PostpaidInquiry mengecek tagihan pascabayar (PLN, BPJS, PDAM, dll)
+func PostpaidInquiry(c *gin.Context) {
+	var req requests.PostpaidInquiryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.ProductPasca
+	err := config.DB.
+		Where("buyer_sku_code = ?", req.BuyerSkuCode).
+		Where("is_active = ? AND buyer_product_status = ? AND seller_product_status = ?", true, true, true).
+		First(&product).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "produk tidak ditemukan atau tidak aktif"})
+		return
+	}
+
+	inquiryID, err := orderid.Default.New(c.Request.Context(), c.GetHeader("X-Storefront"))
+	if err != nil {
+		log.Printf("Failed to generate order_id: %v", err)
+		c.JSON(500, gin.H{"error": "Failed generate order ID"})
+		return
+	}
+
+	result, err := digiflazz.NewFromEnv().InquiryPasca(c.Request.Context(), digiflazz.TransactionRequest{
+		BuyerSkuCode: product.BuyerSkuCode,
+		CustomerNo:   req.CustomerNo,
+		RefID:        inquiryID,
+		Testing:      digiflazz.TestingEnabled(),
+	})
+	if err != nil {
+		log.Printf("Digiflazz inquiry error for %s: %v", inquiryID, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Gagal cek tagihan, silakan coba lagi"})
+		return
+	}
+
+	if result.RC != digiflazz.RCSuccess {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": result.Message,
+			"rc":    result.RC,
+		})
+		return
+	}
+
+	inquiry := buildPostpaidInquiry(inquiryID, product, result)
+
+	record, _ := json.Marshal(inquiry)
+	if err := config.RDB.Set(c.Request.Context(), postpaidInquiryKey(inquiryID), record, postpaidInquiryTTL).Err(); err != nil {
+		log.Printf("Failed to store inquiry %s: %v", inquiryID, err)
+		c.JSON(500, gin.H{"error": "Gagal menyimpan tagihan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tagihan ditemukan",
+		"data": gin.H{
+			"inquiry":    inquiry.view(),
+			"expires_at": time.Now().Add(postpaidInquiryTTL),
+		},
+	})
+}
+
+// buildPostpaidInquiry menghitung tagihan dari response inq-pasca.
+// selling_price Digiflazz = tagihan + admin, price = yang kita bayar ke Digiflazz.
+func buildPostpaidInquiry(inquiryID string, product models.ProductPasca, result *digiflazz.Transaction) postpaidInquiry {
+	total := result.SellingPrice.Int64()
+	if total == 0 {
+		total = result.Price.Int64()
+	}
+	admin := result.Admin.Int64()
+
+	return postpaidInquiry{
+		InquiryID:    inquiryID,
+		BuyerSkuCode: product.BuyerSkuCode,
+		ProductName:  product.ProductName,
+		CustomerNo:   result.CustomerNo,
+		CustomerName: result.CustomerName,
+		Period:       postpaidPeriod(result.Desc),
+		BillAmount:   total - admin,
+		AdminFee:     admin,
+		Total:        total,
+		Price:        result.Price.Int64(),
+		Desc:         result.Desc,
+	}
+}
+
+// postpaidPeriod mengambil periode dari desc.detail[].periode
+func postpaidPeriod(desc json.RawMessage) string {
+	var parsed struct {
+		Detail []struct {
+			Periode string `json:"periode"`
+		} `json:"detail"`
+	}
+	if len(desc) == 0 || json.Unmarshal(desc, &parsed) != nil {
+		return ""
+	}
+
+	periods := make([]string, 0, len(parsed.Detail))
+	for _, detail := range parsed.Detail {
+		if detail.Periode != "" {
+			periods = append(periods, detail.Periode)
+		}
+	}
+	return strings.Join(periods, ", ")
+}
+
+// PostpaidCheckout membuat transaksi pembayaran untuk tagihan hasil inquiry
+func PostpaidCheckout(c *gin.Context) {
+	var req requests.PostpaidCheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	key := postpaidInquiryKey(req.InquiryID)
+
+	raw, err := config.RDB.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan tidak ditemukan atau sudah kedaluwarsa, silakan cek ulang"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Gagal mengambil tagihan"})
+		return
+	}
+
+	var inquiry postpaidInquiry
+	if err := json.Unmarshal(raw, &inquiry); err != nil {
+		c.JSON(500, gin.H{"error": "Data tagihan rusak, silakan cek ulang"})
+		return
+	}
+
+	// Satu inquiry hanya boleh di-checkout sekali
+	claimed, err := config.RDB.SetNX(ctx, key+":checkout", 1, postpaidInquiryTTL).Result()
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Gagal memproses checkout"})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusConflict, gin.H{"error": "Tagihan sedang atau sudah dibayar"})
+		return
+	}
+
+	var paymentMethod models.PaymentMethod
+	err = config.DB.
+		Where("LOWER(name) = ? AND is_active = ?", strings.ToLower(req.PaymentMethodName), true).
+		First(&paymentMethod).Error
+	if err != nil {
+		config.RDB.Del(ctx, key+":checkout")
+		c.JSON(http.StatusBadRequest, gin.H{"error": errPaymentMethodNotFound.Error()})
+		return
+	}
+
+	sellingPrice := decimal.NewFromInt(inquiry.Total)
+	purchasePrice := decimal.NewFromInt(inquiry.Price)
+	fee := paymentMethod.CalculateFee(sellingPrice)
+
+	clientFee := decimal.NewFromFloat(req.Fee).Ceil()
+	if !clientFee.Equal(fee) {
+		config.RDB.Del(ctx, key+":checkout")
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "Harga telah berubah, silakan muat ulang halaman",
+			"field":    "fee",
+			"expected": fee,
+		})
+		return
+	}
+
+	grossAmount := sellingPrice.Add(fee)
+
+	items := []payment.Item{
+		{
+			ID:       inquiry.BuyerSkuCode,
+			Name:     inquiry.ProductName,
+			Price:    sellingPrice,
+			Quantity: 1,
+		},
+	}
+	if fee.GreaterThan(decimal.Zero) {
+		items = append(items, payment.Item{
+			ID:       "fee",
+			Name:     "Biaya Admin",
+			Price:    fee,
+			Quantity: 1,
+		})
+	}
+
+	charged, ok := chargePayment(c, paymentCharge{
+		OrderID:       inquiry.InquiryID,
+		PaymentMethod: paymentMethod,
+		MethodName:    req.PaymentMethodName,
+		GrossAmount:   grossAmount,
+		Items:         items,
+		WaPembeli:     req.WaPembeli,
+		CustomerName:  inquiry.CustomerName,
+	})
+	if !ok {
+		config.RDB.Del(ctx, key+":checkout")
+		return
+	}
+	charge := charged.Result
+
+	chargeResponseJSON, err := json.Marshal(charge.Response)
+	if err != nil {
+		chargeResponseJSON = []byte("{}")
+	}
+
+	var note *string
+	if inquiry.Period != "" {
+		note = stringPtr(fmt.Sprintf("Periode: %s", inquiry.Period))
+	}
+
+	transaction := models.Transaction{
+		ProductName:       stringPtr(inquiry.ProductName),
+		ProductType:       stringPtr("postpaid"),
+		CustomerNo:        inquiry.CustomerNo,
+		BuyerSkuCode:      inquiry.BuyerSkuCode,
+		OrderID:           inquiry.InquiryID,
+		IsTest:            digiflazz.TestingEnabled(),
+		TransactionID:     stringPtr(charge.TransactionID),
+		GrossAmount:       grossAmount,
+		SellingPrice:      sellingPrice,
+		PurchasePrice:     purchasePrice,
+		PaymentGateway:    charged.Gateway.Name(),
+		PaymentType:       stringPtr(charge.PaymentType),
+		PaymentMethodName: stringPtr(req.PaymentMethodName),
+		PaymentStatus:     payment.StatusPending,
+		ExpiredAt:         &charged.ExpiredAt,
+		StatusMessage:     stringPtr(charge.StatusMessage),
+		CustomerName:      stringPtr(inquiry.CustomerName),
+		Note:              note,
+		URL:               stringPtr(charge.PaymentURL),
+		DeeplinkGopay:     stringPtr(charge.Deeplink),
+		WaPembeli:         req.WaPembeli,
+		MidtransResponse:  datatypes.JSON(chargeResponseJSON),
+	}
+
+	if err := config.DB.Create(&transaction).Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed save transaction: " + err.Error()})
+		return
+	}
+
+	config.RDB.Del(ctx, key)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Payment created",
+		"data": gin.H{
+			"transaction":   transaction,
+			"inquiry":       inquiry.view(),
+			"payment_url":   charge.PaymentURL,
+			"deeplink":      charge.Deeplink,
+			"midtrans_data": charge.Response,
+		},
+	})
+}
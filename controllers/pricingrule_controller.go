@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/pricing"
+	"api-arveshop-go/requests"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetPricingRules(c *gin.Context) {
+	var rules []models.PricingRule
+
+	if err := config.DB.Order("scope ASC, priority DESC, id DESC").Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    rules,
+	})
+}
+
+func CreatePricingRule(c *gin.Context) {
+	var req requests.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	rule := pricingRuleFromRequest(req)
+	if err := pricing.Validate(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := config.DB.Create(&rule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menambah rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Berhasil menambah rule",
+		"data":    rule,
+	})
+}
+
+func UpdatePricingRule(c *gin.Context) {
+	var req requests.PricingRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	var rule models.PricingRule
+	if err := config.DB.First(&rule, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Rule tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		}
+		return
+	}
+
+	updated := pricingRuleFromRequest(req)
+	if req.IsActive == nil {
+		updated.IsActive = rule.IsActive
+	}
+	if err := pricing.Validate(&updated); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	err := config.DB.Model(&rule).Updates(map[string]interface{}{
+		"name":         updated.Name,
+		"scope":        updated.Scope,
+		"match_value":  updated.Match,
+		"markup_type":  updated.MarkupType,
+		"markup_value": updated.MarkupValue,
+		"rounding":     updated.Rounding,
+		"min_margin":   updated.MinMargin,
+		"priority":     updated.Priority,
+		"is_active":    updated.IsActive,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengubah data", "error": err.Error()})
+		return
+	}
+	config.DB.First(&rule, rule.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengubah rule",
+		"data":    rule,
+	})
+}
+
+func DeletePricingRule(c *gin.Context) {
+	result := config.DB.Delete(&models.PricingRule{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menghapus"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Rule tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil menghapus"})
+}
+
+// PreviewPricing menampilkan harga jual hasil rule tanpa mengubah produk
+func PreviewPricing(c *gin.Context) {
+	var req requests.PricingPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	var rules []models.PricingRule
+	if err := config.DB.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil rule"})
+		return
+	}
+
+	if req.Rule != nil {
+		candidate := pricingRuleFromRequest(*req.Rule)
+		candidate.IsActive = true
+		if err := pricing.Validate(&candidate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		rules = append(rules, candidate)
+	}
+	engine := pricing.NewEngine(rules)
+
+	query := config.DB.Where("product_type = ?", "prepaid")
+	if req.Category != "" {
+		query = query.Where("category = ?", req.Category)
+	}
+	if req.Brand != "" {
+		query = query.Where("brand = ?", req.Brand)
+	}
+	if req.SKU != "" {
+		query = query.Where("buyer_sku_code = ?", req.SKU)
+	}
+
+	var products []models.Product
+	if err := query.Order("brand ASC, price ASC").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil produk"})
+		return
+	}
+
+	items := make([]gin.H, 0, len(products))
+	changed := 0
+	for _, product := range products {
+		price, rule := engine.SellingPrice(product.Category, product.Brand, product.BuyerSkuCode, product.Price)
+
+		var ruleName *string
+		if rule != nil {
+			ruleName = &rule.Name
+		}
+		if price != product.SellingPrice {
+			changed++
+		}
+
+		items = append(items, gin.H{
+			"buyer_sku_code":        product.BuyerSkuCode,
+			"product_name":          product.ProductName,
+			"category":              product.Category,
+			"brand":                 product.Brand,
+			"price":                 product.Price,
+			"current_selling_price": product.SellingPrice,
+			"new_selling_price":     price,
+			"margin":                price - product.Price,
+			"rule":                  ruleName,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil",
+		"changed": changed,
+		"data":    items,
+	})
+}
+
+// ApplyPricing menghitung ulang selling_price semua produk dengan rule aktif
+func ApplyPricing(c *gin.Context) {
+	engine, err := pricing.Load(c.Request.Context(), config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil rule"})
+		return
+	}
+
+	changed, err := pricing.Reprice(c.Request.Context(), config.DB, engine)
+	if err != nil {
+		log.Printf("Gagal reprice produk: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menerapkan harga", "changed": changed})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Harga jual diperbarui",
+		"changed": changed,
+	})
+}
+
+func pricingRuleFromRequest(req requests.PricingRuleRequest) models.PricingRule {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return models.PricingRule{
+		Name:        req.Name,
+		Scope:       req.Scope,
+		Match:       req.Match,
+		MarkupType:  req.MarkupType,
+		MarkupValue: req.MarkupValue,
+		Rounding:    req.Rounding,
+		MinMargin:   req.MinMargin,
+		Priority:    req.Priority,
+		IsActive:    isActive,
+	}
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveJSON menjalankan handler dengan body JSON dan mengembalikan recorder
+func serveJSON(t *testing.T, handler gin.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(method, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handler(c)
+	return recorder
+}
+
+func TestCreatePricingRuleKeepsInactive(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"draft nonaktif", `{"name":"draft","scope":"global","markup_type":"flat","markup_value":500,"is_active":false}`, false},
+		{"is_active kosong berarti aktif", `{"name":"aktif","scope":"global","markup_type":"flat","markup_value":500}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useTestDB(t)
+
+			recorder := serveJSON(t, CreatePricingRule, http.MethodPost, tt.body)
+			if recorder.Code != http.StatusCreated {
+				t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body)
+			}
+
+			inserts := fake.Execs("INSERT INTO `pricing_rules`")
+			if len(inserts) != 1 {
+				t.Fatalf("insert pricing_rules = %d, want 1", len(inserts))
+			}
+			row := inserts[0].Row()
+			if got, ok := row["is_active"].(bool); !ok || got != tt.want {
+				t.Fatalf("is_active tersimpan = %v, want %v", row["is_active"], tt.want)
+			}
+
+			// Baca kembali baris yang tersimpan lewat GetPricingRules
+			row["id"] = int64(1)
+			fake.SetRows("pricing_rules", row)
+			recorder = serveJSON(t, GetPricingRules, http.MethodGet, "")
+
+			var response struct {
+				Data []struct {
+					Name     string `json:"name"`
+					IsActive bool   `json:"is_active"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Data) != 1 || response.Data[0].IsActive != tt.want {
+				t.Errorf("rule dibaca kembali = %+v, want is_active %v", response.Data, tt.want)
+			}
+		})
+	}
+}
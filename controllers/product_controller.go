@@ -3,7 +3,9 @@ package controllers
 import (
 	"api-arveshop-go/config"
 	"api-arveshop-go/models"
+	"api-arveshop-go/seller"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +18,7 @@ func GetProductHome(p *gin.Context) {
     
     // Gunakan First untuk single record, Find untuk multiple
     err := config.DB.
+        Where("is_active = ?", true).
         Where("seller_product_status = ?", true).
         Where("buyer_product_status = ?", true).
         Where("slug = ?", slug).
@@ -31,4 +34,72 @@ func GetProductHome(p *gin.Context) {
     }
 
     p.JSON(http.StatusOK, gin.H{"message": "Berhasil", "data": products})
-}
\ No newline at end of file
+}
+// GetProductAvailability dipanggil sebelum checkout untuk memperingatkan pembeli
+// jika produk sedang cutoff atau stok habis
+func GetProductAvailability(c *gin.Context) {
+	var product models.Product
+	err := config.DB.
+		Where("buyer_sku_code = ?", c.Param("sku")).
+		Where("is_active = ?", true).
+		First(&product).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Data tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil",
+		"data": gin.H{
+			"buyer_sku_code": product.BuyerSkuCode,
+			"available":      product.BuyerProductStatus && product.SellerProductStatus && product.IsStockAvailable(),
+			"in_stock":       product.IsStockAvailable(),
+			"cutoff":         cutoffNotice(&product),
+		},
+	})
+}
+
+// cutoffNotice peringatan untuk pembeli jika produk sedang cutoff, nil jika tidak
+func cutoffNotice(product *models.Product) gin.H {
+	if !product.IsWithinCutoff() {
+		return nil
+	}
+
+	processAt := product.NextProcessTime()
+	return gin.H{
+		"start_cut_off": product.StartCutOff,
+		"end_cut_off":   product.EndCutOff,
+		"process_at":    processAt,
+		"message": fmt.Sprintf("Produk sedang cutoff (%s - %s), pesanan akan diproses pukul %s",
+			product.StartCutOff, product.EndCutOff, processAt.Format("15:04")),
+	}
+}
+
+// GetSellerOptions menampilkan peringkat seller setara untuk satu produk (admin)
+func GetSellerOptions(c *gin.Context) {
+	var product models.Product
+	if err := config.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Data tidak ditemukan"})
+		return
+	}
+
+	policy := seller.PolicyFromEnv()
+	if name := c.Query("policy"); name != "" {
+		policy.Name = name
+	}
+
+	options, err := seller.Options(c.Request.Context(), config.DB, &product, policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil",
+		"data": gin.H{
+			"group_key": seller.GroupKey(&product),
+			"policy":    policy.Name,
+			"options":   options,
+		},
+	})
+}
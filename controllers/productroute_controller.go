@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/requests"
+	"api-arveshop-go/supplier"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetProductRoutes(c *gin.Context) {
+	var routes []models.ProductRoute
+
+	query := config.DB.Order("product_id ASC, priority ASC, id ASC")
+	if productID := c.Query("product_id"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+
+	if err := query.Find(&routes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    routes,
+	})
+}
+
+func CreateProductRoute(c *gin.Context) {
+	var req requests.ProductRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+	if !validateProductRoute(c, req) {
+		return
+	}
+
+	route := models.ProductRoute{
+		ProductID: req.ProductID,
+		Priority:  req.Priority,
+		Provider:  req.Provider,
+		SKU:       req.SKU,
+		IsActive:  req.IsActive == nil || *req.IsActive,
+	}
+	if err := config.DB.Create(&route).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menambah route"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Berhasil menambah route",
+		"data":    route,
+	})
+}
+
+func UpdateProductRoute(c *gin.Context) {
+	var req requests.ProductRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	var route models.ProductRoute
+	if err := config.DB.First(&route, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Route tidak ditemukan"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		}
+		return
+	}
+	if !validateProductRoute(c, req) {
+		return
+	}
+
+	isActive := route.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	err := config.DB.Model(&route).Updates(map[string]interface{}{
+		"product_id": req.ProductID,
+		"priority":   req.Priority,
+		"provider":   req.Provider,
+		"sku":        req.SKU,
+		"is_active":  isActive,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengubah data", "error": err.Error()})
+		return
+	}
+	config.DB.First(&route, route.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengubah route",
+		"data":    route,
+	})
+}
+
+func DeleteProductRoute(c *gin.Context) {
+	result := config.DB.Delete(&models.ProductRoute{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal menghapus"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Route tidak ditemukan"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Berhasil menghapus"})
+}
+
+// GetFulfilmentAttempts riwayat percobaan pengiriman satu transaksi ke supplier
+func GetFulfilmentAttempts(c *gin.Context) {
+	var attempts []models.FulfilmentAttempt
+	if err := config.DB.Where("order_id = ?", c.Param("order_id")).Order("attempt ASC").Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    attempts,
+	})
+}
+
+// validateProductRoute memastikan produk ada & provider terdaftar.
+// Jika tidak valid, response error sudah ditulis.
+func validateProductRoute(c *gin.Context, req requests.ProductRouteRequest) bool {
+	if err := config.DB.Select("id").First(&models.Product{}, req.ProductID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Produk tidak ditemukan"})
+		return false
+	}
+	if _, err := supplier.Get(req.Provider); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"api-arveshop-go/supplier"
+	"api-arveshop-go/testdb"
+	"context"
+	"net/http"
+	"testing"
+)
+
+type stubSupplier struct{}
+
+func (stubSupplier) Name() string { return "route-test" }
+func (stubSupplier) Topup(context.Context, supplier.Request) (*supplier.Result, error) {
+	return nil, supplier.ErrNotSupported
+}
+func (stubSupplier) Status(context.Context, supplier.Request) (*supplier.Result, error) {
+	return nil, supplier.ErrNotSupported
+}
+
+func TestCreateProductRouteKeepsInactive(t *testing.T) {
+	supplier.Register(stubSupplier{})
+
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"route nonaktif", `{"product_id":7,"priority":1,"provider":"route-test","sku":"TSEL5B","is_active":false}`, false},
+		{"is_active kosong berarti aktif", `{"product_id":7,"priority":1,"provider":"route-test","sku":"TSEL5B"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useTestDB(t)
+			fake.SetRows("products", testdb.Row{"id": int64(7)})
+
+			recorder := serveJSON(t, CreateProductRoute, http.MethodPost, tt.body)
+			if recorder.Code != http.StatusCreated {
+				t.Fatalf("status = %d, body = %s", recorder.Code, recorder.Body)
+			}
+
+			inserts := fake.Execs("INSERT INTO `product_routes`")
+			if len(inserts) != 1 {
+				t.Fatalf("insert product_routes = %d, want 1", len(inserts))
+			}
+			if got, ok := inserts[0].Row()["is_active"].(bool); !ok || got != tt.want {
+				t.Errorf("is_active tersimpan = %v, want %v", inserts[0].Row()["is_active"], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/refund"
+	"api-arveshop-go/requests"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetRefunds(c *gin.Context) {
+	var refunds []models.Refund
+
+	query := config.DB.Order("created_at DESC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Find(&refunds).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    refunds,
+	})
+}
+
+// CreateRefund membuat refund manual dari admin untuk transaksi yang sudah dibayar
+func CreateRefund(c *gin.Context) {
+	var req requests.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	var transaction models.Transaction
+	if err := config.DB.Where("order_id = ?", req.OrderID).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Transaksi tidak ditemukan"})
+		return
+	}
+
+	result, err := refund.Request(c.Request.Context(), config.DB, &transaction, req.Reason)
+	if err != nil {
+		if errors.Is(err, refund.ErrNotRefundable) {
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal membuat refund", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Refund dibuat",
+		"data":    result,
+	})
+}
+
+func ApproveRefund(c *gin.Context) {
+	var req requests.ApproveRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Data tidak valid", "error": err.Error()})
+		return
+	}
+
+	record, ok := findRefund(c)
+	if !ok {
+		return
+	}
+
+	err := refund.Approve(config.DB, record, req.BankName, req.AccountNumber, req.AccountName, req.Note)
+	respondRefundAction(c, record, err, "Refund disetujui")
+}
+
+func CompleteRefund(c *gin.Context) {
+	var req requests.RefundNoteRequest
+	c.ShouldBindJSON(&req)
+
+	record, ok := findRefund(c)
+	if !ok {
+		return
+	}
+
+	err := refund.Complete(config.DB, record, req.Note)
+	respondRefundAction(c, record, err, "Refund selesai")
+}
+
+func RejectRefund(c *gin.Context) {
+	var req requests.RefundNoteRequest
+	c.ShouldBindJSON(&req)
+
+	record, ok := findRefund(c)
+	if !ok {
+		return
+	}
+
+	err := refund.Reject(config.DB, record, req.Note)
+	respondRefundAction(c, record, err, "Refund ditolak")
+}
+
+func findRefund(c *gin.Context) (*models.Refund, bool) {
+	var record models.Refund
+	if err := config.DB.First(&record, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Data tidak ditemukan"})
+		return nil, false
+	}
+	return &record, true
+}
+
+func respondRefundAction(c *gin.Context, record *models.Refund, err error, message string) {
+	if err != nil {
+		switch {
+		case errors.Is(err, refund.ErrInvalidState):
+			c.JSON(http.StatusConflict, gin.H{"message": err.Error(), "status": record.Status})
+		case errors.Is(err, refund.ErrMissingAccount):
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengupdate refund", "error": err.Error()})
+		}
+		return
+	}
+
+	config.DB.First(record, record.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    record,
+	})
+}
@@ -3,14 +3,16 @@ package controllers
 
 import (
 	"api-arveshop-go/config"
+	"api-arveshop-go/digiflazz"
 	"api-arveshop-go/jobs"
 	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/requests"
+	"api-arveshop-go/txstate"
 	"api-arveshop-go/websocket"
 	"bytes"
 	"context"
-	"crypto/sha512"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
@@ -22,38 +24,6 @@ import (
 	"gorm.io/datatypes"
 )
 
-// MidtransNotification struct untuk menampung data dari Midtrans
-type MidtransNotification struct {
-	TransactionID     string `json:"transaction_id"`
-	OrderID           string `json:"order_id"`
-	PaymentType       string `json:"payment_type"`
-	TransactionTime   string `json:"transaction_time"`
-	TransactionStatus string `json:"transaction_status"`
-	GrossAmount       string `json:"gross_amount"`
-	StatusCode        string `json:"status_code"`
-	StatusMessage     string `json:"status_message"`
-	SignatureKey      string `json:"signature_key"`
-	MerchantID        string `json:"merchant_id"`
-	
-	// Untuk VA
-	VaNumbers         []struct {
-		Bank     string `json:"bank"`
-		VaNumber string `json:"va_number"`
-	} `json:"va_numbers,omitempty"`
-	
-	// Untuk QRIS / E-Wallet
-	Actions           []struct {
-		Name   string `json:"name"`
-		Method string `json:"method"`
-		URL    string `json:"url"`
-	} `json:"actions,omitempty"`
-	
-	// Untuk Kartu Kredit
-	ApprovalCode      string `json:"approval_code,omitempty"`
-	FraudStatus       string `json:"fraud_status,omitempty"`
-	Currency          string `json:"currency,omitempty"`
-}
-
 func HandleMidtransWebhook(c *gin.Context) {
 	// Baca body request
 	bodyBytes, err := io.ReadAll(c.Request.Body)
@@ -69,18 +39,23 @@ func HandleMidtransWebhook(c *gin.Context) {
 	// Log untuk debugging
 	log.Printf("Midtrans Webhook received: %s", string(bodyBytes))
 	
-	// Parse JSON
-	var notification MidtransNotification
-	if err := json.Unmarshal(bodyBytes, &notification); err != nil {
-		log.Printf("Error parsing webhook JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
+	gateway, err := payment.Get("midtrans")
+	if err != nil {
+		log.Printf("Midtrans gateway not registered: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gateway not configured"})
 		return
 	}
-	
-	// Validasi signature key (keamanan)
-	if !validateSignature(notification) {
-		log.Printf("Invalid signature key for order: %s", notification.OrderID)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+
+	// Parse JSON & validasi signature key (keamanan)
+	notification, err := gateway.VerifyNotification(bodyBytes, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			log.Printf("Invalid signature key for webhook: %s", string(bodyBytes))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+			return
+		}
+		log.Printf("Error parsing webhook JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
 		return
 	}
 	
@@ -93,26 +68,13 @@ func HandleMidtransWebhook(c *gin.Context) {
 	}
 	
 	// Update transaksi berdasarkan notifikasi
-	newStatus, err := updateTransactionFromWebhook(&transaction, notification, bodyBytes)
+	newStatus, err := ApplyPaymentNotification(&transaction, *notification)
 	if err != nil {
 		log.Printf("Error updating transaction: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
 		return
 	}
 	
-	// 🟢 AMBIL DATA TERBARU setelah update
-	var updatedTransaction models.Transaction
-	config.DB.Where("order_id = ?", notification.OrderID).First(&updatedTransaction)
-	
-	// 🟢 BROADCAST VIA WEBSOCKET dengan data lengkap
-	log.Printf("📢 Broadcasting settlement for order %s via WebSocket", notification.OrderID)
-	websocket.BroadcastOrderStatusWithData(notification.OrderID, updatedTransaction)
-	
-	// Trigger Digiflazz jika settlement
-	if notification.TransactionStatus == "settlement" || notification.TransactionStatus == "capture" {
-		go triggerDigiflazzProcessing(&updatedTransaction)
-	}
-	
 	// Selalu return 200 OK ke Midtrans
 	c.JSON(http.StatusOK, gin.H{
 		"status":  newStatus,
@@ -120,47 +82,82 @@ func HandleMidtransWebhook(c *gin.Context) {
 	})
 }
 
-// Validasi signature key dari Midtrans
-func validateSignature(notification MidtransNotification) bool {
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	if serverKey == "" {
-		log.Println("WARNING: MIDTRANS_SERVER_KEY not set, skipping signature validation")
-		return true
+func HandleTripayWebhook(c *gin.Context) {
+	bodyBytes, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Printf("Error reading Tripay callback body: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Failed to read body"})
+		return
 	}
-	
-	// Format: order_id + status_code + gross_amount + server_key
-	signatureString := notification.OrderID + notification.StatusCode + 
-		notification.GrossAmount + serverKey
-	
-	// Generate SHA512 hash
-	hash := sha512.New()
-	hash.Write([]byte(signatureString))
-	expectedSignature := hex.EncodeToString(hash.Sum(nil))
-	
-	// Compare with received signature
-	return expectedSignature == notification.SignatureKey
+
+	log.Printf("Tripay Callback received: %s", string(bodyBytes))
+
+	gateway, err := payment.Get("tripay")
+	if err != nil {
+		log.Printf("Tripay gateway not registered: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gateway not configured"})
+		return
+	}
+
+	notification, err := gateway.VerifyNotification(bodyBytes, c.Request.Header)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidSignature) {
+			log.Printf("Invalid Tripay callback signature: %s", string(bodyBytes))
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Invalid signature"})
+			return
+		}
+		log.Printf("Error parsing Tripay callback: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	var transaction models.Transaction
+	if err := config.DB.Where("order_id = ?", notification.OrderID).First(&transaction).Error; err != nil {
+		log.Printf("Transaction not found for merchant_ref: %s", notification.OrderID)
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Transaction not found"})
+		return
+	}
+
+	if _, err := ApplyPaymentNotification(&transaction, *notification); err != nil {
+		log.Printf("Error updating transaction: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update transaction"})
+		return
+	}
+
+	// Tripay mengharapkan {"success": true}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ApplyPaymentNotification menyimpan notifikasi gateway ke transaksi,
+// lalu broadcast data terbaru via WebSocket. Dipakai webhook dan reconciler.
+func ApplyPaymentNotification(transaction *models.Transaction, notification payment.Notification) (string, error) {
+	newStatus, err := updateTransactionFromWebhook(transaction, notification)
+	if err != nil {
+		return "", err
+	}
+
+	// 🟢 AMBIL DATA TERBARU setelah update
+	var updatedTransaction models.Transaction
+	config.DB.Where("order_id = ?", transaction.OrderID).First(&updatedTransaction)
+
+	// 🟢 BROADCAST VIA WEBSOCKET dengan data lengkap
+	log.Printf("📢 Broadcasting %s for order %s via WebSocket", newStatus, transaction.OrderID)
+	websocket.BroadcastOrderStatusWithData(transaction.OrderID, updatedTransaction)
+
+	return newStatus, nil
 }
 
 // Update transaksi berdasarkan data webhook
 func updateTransactionFromWebhook(
 	transaction *models.Transaction, 
-	notification MidtransNotification, 
-	rawBody []byte,
+	notification payment.Notification, 
 ) (string, error) {
-	// Mapping status Midtrans ke status aplikasi
-	newStatus := mapMidtransStatus(notification.TransactionStatus)
-	
-	// Parse gross amount dari string ke decimal
-	grossAmount, err := parseGrossAmount(notification.GrossAmount)
-	if err != nil {
-		log.Printf("Error parsing gross amount: %v", err)
-	}
+	newStatus := notification.Status
+	grossAmount := notification.GrossAmount
 	
 	// Siapkan data update
 	updates := map[string]interface{}{
-		"payment_status":   newStatus,
 		"status_message":   notification.StatusMessage,
-		"updated_at":       time.Now(),
 	}
 	
 	// Update TransactionID jika belum ada
@@ -178,74 +175,57 @@ func updateTransactionFromWebhook(
 		updates["payment_type"] = notification.PaymentType
 	}
 	
-	// Simpan raw Midtrans response
-	updates["midtrans_response"] = datatypes.JSON(rawBody)
+	// Simpan raw response gateway
+	updates["midtrans_response"] = datatypes.JSON(notification.Raw)
 	
-	// Jika settlement (sukses), trigger Digiflazz
-	if notification.TransactionStatus == "settlement" || notification.TransactionStatus == "capture" {
-		// Trigger proses pengiriman ke Digiflazz
-		go triggerDigiflazzProcessing(transaction)
+	change := txstate.Change{
+		Payment: newStatus,
+		Source:  txstate.SourceGateway,
+		Note:    transaction.PaymentGateway + ": " + notification.GatewayStatus,
+		Updates: updates,
 	}
 	
-	// Jika gagal/expired, update status
-	if newStatus == "failed" || newStatus == "expired" {
-		digiflazzStatus := "Gagal"
-		updates["digiflazz_status"] = &digiflazzStatus
+	switch newStatus {
+	case payment.StatusFailed, payment.StatusExpired, payment.StatusCancelled:
+		// Pembayaran gagal, pengiriman tidak akan diproses
+		change.Fulfilment = txstate.FulfilmentCancelled
+	case payment.StatusSettlement:
+		// Pembayaran terlambat setelah expired, buka lagi pengirimannya
+		if transaction.PaymentStatus == payment.StatusExpired {
+			change.Fulfilment = txstate.FulfilmentPending
+		}
 	}
 	
-	// Update ke database
-	if err := config.DB.Model(transaction).Updates(updates).Error; err != nil {
+	result, err := txstate.Apply(context.Background(), config.DB, transaction, change)
+	if errors.Is(err, txstate.ErrIllegalTransition) {
+		// Notifikasi lama / tidak berurutan, abaikan tanpa membuat gateway retry
+		log.Printf("⚠️ Notifikasi %s diabaikan: %v", notification.OrderID, err)
+		return transaction.PaymentStatus, nil
+	}
+	if err != nil {
 		return "", err
 	}
 	
 	log.Printf("Transaction %s updated: payment_status=%s", 
 		notification.OrderID, newStatus)
 	
-	return newStatus, nil
-}
-
-// Mapping status Midtrans ke status aplikasi
-func mapMidtransStatus(midtransStatus string) string {
-	switch midtransStatus {
-	case "capture", "settlement":
-		return "settlement"
-	case "pending":
-		return "pending"
-	case "deny", "cancel", "expire", "failure":
-		return "failed"
-	case "refund":
-		return "refunded"
-	case "partial_refund":
-		return "partial_refund"
-	default:
-		return "unknown"
+	// Jika baru settlement (sukses), trigger Digiflazz
+	if notification.IsPaid() && result.PaymentChanged() {
+		// Trigger proses pengiriman ke Digiflazz
+		go triggerFulfilment(transaction)
 	}
+	
+	return newStatus, nil
 }
 
-// Parse gross amount dari format Midtrans (string) ke decimal
-func parseGrossAmount(grossAmountStr string) (decimal.Decimal, error) {
-	// Midtrans format: "10000.00" atau "10000"
-	amount, err := decimal.NewFromString(grossAmountStr)
-	if err != nil {
-		return decimal.Zero, err
-	}
-	return amount, nil
-}
+// triggerFulfilment bisa diganti di test supaya tidak menjalankan job Digiflazz
+var triggerFulfilment = triggerDigiflazzProcessing
 
 // Trigger proses pengiriman ke Digiflazz
 func triggerDigiflazzProcessing(transaction *models.Transaction) {
     log.Printf("Triggering Digiflazz for order: %s", transaction.OrderID)
 
-    job := jobs.NewDigiflazzTopupJob(
-        transaction.ID,
-        config.DB,
-        config.RDB, // redis client kamu
-        jobs.DigiflazzConfig{
-            Username: os.Getenv("DIGIFLAZZ_USERNAME"),
-            ProdKey:  os.Getenv("DIGIFLAZZ_PROD_KEY"),
-            BaseURL:  "https://api.digiflazz.com",
-        },
-    )
+    job := newDigiflazzTopupJob(transaction.ID)
 
     go func() {
         ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
@@ -257,9 +237,22 @@ func triggerDigiflazzProcessing(transaction *models.Transaction) {
     }()
 }
 
+func newDigiflazzTopupJob(transactionID uint) *jobs.DigiflazzTopupJob {
+    return jobs.NewDigiflazzTopupJob(
+        transactionID,
+        config.DB,
+        config.RDB, // redis client kamu
+        jobs.DigiflazzConfig{
+            Username: os.Getenv("DIGIFLAZZ_USERNAME"),
+            ProdKey:  digiflazz.APIKeyFromEnv(),
+            BaseURL:  os.Getenv("DIGIFLAZZ_BASE_URL"),
+        },
+    )
+}
+
 // Endpoint untuk testing webhook
 func TestMidtransWebhook(c *gin.Context) {
-	var notification MidtransNotification
+	var notification payment.MidtransNotification
 	if err := c.ShouldBindJSON(&notification); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -275,18 +268,18 @@ func TestMidtransWebhook(c *gin.Context) {
 	})
 }
 
-// Endpoint untuk manual update status (admin)
+// Endpoint untuk manual update status (admin), tetap lewat state machine
 func ManualUpdateStatus(c *gin.Context) {
-	var req struct {
-		OrderID         string `json:"order_id" binding:"required"`
-		PaymentStatus   string `json:"payment_status" binding:"required"`
-		DigiflazzStatus string `json:"digiflazz_status"`
-	}
+	var req requests.UpdateTransactionStatusRequest
 	
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.PaymentStatus == "" && req.DigiflazzStatus == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "payment_status atau digiflazz_status wajib diisi"})
+		return
+	}
 	
 	// Cari transaksi
 	var transaction models.Transaction
@@ -295,46 +288,73 @@ func ManualUpdateStatus(c *gin.Context) {
 		return
 	}
 	
-	// Update status
-	updates := map[string]interface{}{
-		"payment_status": req.PaymentStatus,
-		"updated_at":     time.Now(),
+	change := txstate.Change{
+		Payment:    req.PaymentStatus,
+		Fulfilment: txstate.NormalizeFulfilment(req.DigiflazzStatus),
+		Source:     txstate.SourceAdmin,
+		Note:       req.Note,
 	}
-	
-	if req.DigiflazzStatus != "" {
-		updates["digiflazz_status"] = req.DigiflazzStatus
+	if req.Note != "" {
+		change.Updates = map[string]interface{}{"status_message": req.Note}
 	}
 	
-	if err := config.DB.Model(&transaction).Updates(updates).Error; err != nil {
+	result, err := txstate.Apply(c.Request.Context(), config.DB, &transaction, change)
+	if err != nil {
+		if errors.Is(err, txstate.ErrIllegalTransition) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
+	if result.PaymentChanged() && transaction.PaymentStatus == payment.StatusSettlement {
+		go triggerFulfilment(&transaction)
+	}
+	
+	// Broadcast update via WebSocket
+	websocket.BroadcastOrderStatus(transaction.OrderID)
+	
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Status updated",
-		"data":    transaction,
+		"data":    result,
 	})
 }
 
+// GetEscalatedTransactions order yang tetap pending setelah batas waktu cek status
+func GetEscalatedTransactions(c *gin.Context) {
+	var transactions []models.Transaction
+	err := config.DB.
+		Where("escalated_at IS NOT NULL").
+		Where("digiflazz_status = ?", txstate.FulfilmentPending).
+		Order("escalated_at ASC").
+		Find(&transactions).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    transactions,
+	})
+}
 
-// Struktur yang sesuai dengan payload Digiflazz
-type DigiflazzWebhookPayload struct {
-	Data struct {
-		RefID          string `json:"ref_id"`  // 🔴 INI AKAN BERISI ORDER_ID KITA
-		TrxID          string `json:"trx_id"`
-		CustomerNo     string `json:"customer_no"`
-		BuyerSkuCode   string `json:"buyer_sku_code"`
-		Message        string `json:"message"`
-		Status         string `json:"status"`
-		RC             string `json:"rc"`
-		BuyerLastSaldo int    `json:"buyer_last_saldo"`
-		SN             string `json:"sn"`
-		Price          int    `json:"price"`
-		Tele           string `json:"tele"`
-		Wa             string `json:"wa"`
-	} `json:"data"`
+// GetTransactionStatusLogs riwayat perpindahan status satu transaksi
+func GetTransactionStatusLogs(c *gin.Context) {
+	var logs []models.TransactionStatusLog
+	if err := config.DB.Where("order_id = ?", c.Param("order_id")).Order("id ASC").Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Gagal mengambil data"})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Berhasil mengambil data",
+		"data":    logs,
+	})
 }
 
+
 func HandleDigiflazzWebhook(c *gin.Context) {
 	// Baca body request
 	bodyBytes, err := io.ReadAll(c.Request.Body)
@@ -349,61 +369,52 @@ func HandleDigiflazzWebhook(c *gin.Context) {
 	
 	// Log untuk debugging
 	log.Printf("📥 Digiflazz Webhook received: %s", string(bodyBytes))
+
+	// Validasi signature, event & IP sebelum memproses apa pun
+	if err := digiflazz.NewWebhookVerifierFromEnv().Verify(bodyBytes, c.Request.Header, c.ClientIP()); err != nil {
+		log.Printf("🚫 Digiflazz webhook ditolak dari %s: %v", c.ClientIP(), err)
+		switch {
+		case errors.Is(err, digiflazz.ErrIPNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		case errors.Is(err, digiflazz.ErrUnknownEvent):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event"})
+		case errors.Is(err, digiflazz.ErrWebhookSecretMissing):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Webhook not configured"})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		}
+		return
+	}
 	
 	// Parse JSON
-	var payload DigiflazzWebhookPayload
-	if err := json.Unmarshal(bodyBytes, &payload); err != nil {
+	data, err := digiflazz.ParseWebhook(bodyBytes)
+	if err != nil {
 		log.Printf("Error parsing webhook JSON: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON format"})
 		return
 	}
 	
-	// 🔴 AMBIL ORDER_ID DARI REF_ID
-	data := payload.Data
-	orderID := data.RefID
-	
-	if orderID == "" {
-		log.Printf("❌ RefID (OrderID) kosong dalam webhook")
+	// 🔴 REF_ID = ORDER_ID (percobaan pertama) atau ORDER_ID-n (fallback supplier)
+	if data.RefID == "" {
+		log.Printf("❌ RefID kosong dalam webhook")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "RefID is empty"})
 		return
 	}
 	
-	log.Printf("📦 Processing webhook for order: %s, status: %s", orderID, data.Status)
+	log.Printf("📦 Processing webhook for ref_id: %s, status: %s", data.RefID, data.Status)
 	
-	// Cari transaksi berdasarkan OrderID
-	var transaction models.Transaction
-	if err := config.DB.Where("order_id = ?", orderID).First(&transaction).Error; err != nil {
-		log.Printf("❌ Transaction not found for order_id: %s", orderID)
+	transaction, err := findTransactionByRefID(data.RefID)
+	if err != nil {
+		log.Printf("❌ Transaction not found for ref_id: %s", data.RefID)
 		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
 		return
 	}
+	orderID := transaction.OrderID
 	
-	// Update status berdasarkan webhook
-	statusMessage := data.Message
-	updates := map[string]interface{}{
-		"digiflazz_status":  data.Status,
-		"status_message":    &statusMessage,
-		"serial_number":     &data.SN,
-		"updated_at":        time.Now(),
-	}
-	
-	// Simpan trx_id untuk referensi (optional)
-	if data.TrxID != "" {
-		updates["transaction_id"] = &data.TrxID
-	}
-	
-	// Update status pembayaran jika perlu
-	if data.Status == "Sukses" {
-		updates["payment_status"] = "success"
-		log.Printf("✅ Transaksi %s sukses via webhook", orderID)
-	} else if data.Status == "Gagal" {
-		updates["payment_status"] = "failed"
-		log.Printf("❌ Transaksi %s gagal via webhook", orderID)
-	}
-	
-	// Update ke database
-	if err := config.DB.Model(&transaction).Updates(updates).Error; err != nil {
-		log.Printf("❌ Error updating transaction: %v", err)
+	// Proses lewat logika yang sama dengan job: sukses, pending, atau gagal
+	// (saldo dikembalikan sekali & refund customer jika sudah dibayar)
+	if err := newDigiflazzTopupJob(transaction.ID).HandleCallback(c.Request.Context(), data); err != nil {
+		log.Printf("❌ Error applying Digiflazz callback for %s: %v", orderID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
 		return
 	}
@@ -416,4 +427,19 @@ func HandleDigiflazzWebhook(c *gin.Context) {
 		"status":  "success",
 		"message": "Webhook received",
 	})
-}
\ No newline at end of file
+}
+
+// findTransactionByRefID mencari transaksi dari ref_id percobaan supplier,
+// fallback ke order_id untuk transaksi sebelum ada FulfilmentAttempt
+func findTransactionByRefID(refID string) (*models.Transaction, error) {
+	var transaction models.Transaction
+	
+	var attempt models.FulfilmentAttempt
+	if err := config.DB.Where("ref_id = ?", refID).First(&attempt).Error; err == nil {
+		err := config.DB.First(&transaction, attempt.TransactionID).Error
+		return &transaction, err
+	}
+	
+	err := config.DB.Where("order_id = ?", refID).First(&transaction).Error
+	return &transaction, err
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/testdb"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// useTestDB mengganti config.DB dengan database palsu selama test
+func useTestDB(t *testing.T) *testdb.DB {
+	t.Helper()
+	db, fake := testdb.Open(t)
+
+	previous := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = previous })
+	return fake
+}
+
+// ─── ApplyPaymentNotification ─────────────────────────────────────────────────
+
+func TestApplyPaymentNotificationWithFakeGateway(t *testing.T) {
+	fake := useTestDB(t)
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	triggered := make(chan string, 1)
+	previousTrigger := triggerFulfilment
+	triggerFulfilment = func(transaction *models.Transaction) { triggered <- transaction.OrderID }
+	t.Cleanup(func() { triggerFulfilment = previousTrigger })
+
+	tests := []struct {
+		name string
+		// Status transaksi di database sebelum notifikasi
+		paymentStatus string
+		// Mengubah charge di fake gateway, mensimulasikan aksi pembeli
+		simulate func(f *payment.FakeGateway, orderID string)
+
+		wantStatus  string
+		wantUpdate  bool
+		wantLogs    int
+		wantTrigger bool
+		wantArg     string
+	}{
+		{
+			name:          "pending dibayar",
+			paymentStatus: payment.StatusPending,
+			simulate: func(f *payment.FakeGateway, orderID string) {
+				f.SetStatus(orderID, payment.StatusSettlement, "settlement")
+			},
+			wantStatus:  payment.StatusSettlement,
+			wantUpdate:  true,
+			wantLogs:    1,
+			wantTrigger: true,
+			wantArg:     payment.StatusSettlement,
+		},
+		{
+			name:          "pending dibatalkan",
+			paymentStatus: payment.StatusPending,
+			simulate: func(f *payment.FakeGateway, orderID string) {
+				f.Cancel(context.Background(), payment.Reference{OrderID: orderID})
+			},
+			wantStatus: payment.StatusCancelled,
+			wantUpdate: true,
+			wantLogs:   1,
+			wantArg:    "cancelled",
+		},
+		{
+			name:          "settlement dikirim ulang",
+			paymentStatus: payment.StatusSettlement,
+			simulate: func(f *payment.FakeGateway, orderID string) {
+				f.SetStatus(orderID, payment.StatusSettlement, "settlement")
+			},
+			wantStatus: payment.StatusSettlement,
+			wantUpdate: true,
+			wantLogs:   0,
+		},
+		{
+			name:          "notifikasi pending terlambat setelah settlement",
+			paymentStatus: payment.StatusSettlement,
+			simulate:      func(f *payment.FakeGateway, orderID string) {},
+			wantStatus:    payment.StatusSettlement,
+			wantUpdate:    false,
+			wantLogs:      0,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := payment.NewFakeGateway("fake")
+			orderID := fmt.Sprintf("ARV-TEST-%d", i)
+
+			if _, err := gateway.Charge(context.Background(), payment.ChargeRequest{
+				OrderID:       orderID,
+				GrossAmount:   decimal.NewFromInt(10150),
+				PaymentMethod: "qris",
+			}); err != nil {
+				t.Fatalf("Charge() error = %v", err)
+			}
+			tt.simulate(gateway, orderID)
+
+			notification, err := gateway.Status(context.Background(), payment.Reference{OrderID: orderID})
+			if err != nil {
+				t.Fatalf("Status() error = %v", err)
+			}
+
+			fake.Reset()
+			fake.SetRows("transactions", testdb.Row{
+				"id":              int64(1),
+				"order_id":        orderID,
+				"payment_status":  tt.paymentStatus,
+				"payment_gateway": gateway.Name(),
+				"gross_amount":    "10150",
+			})
+			transaction := models.Transaction{
+				ID:             1,
+				OrderID:        orderID,
+				PaymentStatus:  tt.paymentStatus,
+				PaymentGateway: gateway.Name(),
+				GrossAmount:    decimal.NewFromInt(10150),
+			}
+
+			status, err := ApplyPaymentNotification(&transaction, *notification)
+			if err != nil {
+				t.Fatalf("ApplyPaymentNotification() error = %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+
+			updates := fake.Execs("UPDATE `transactions`")
+			if got := len(updates) > 0; got != tt.wantUpdate {
+				t.Fatalf("transaksi di-update = %v, want %v", got, tt.wantUpdate)
+			}
+			if tt.wantArg != "" && !updates[0].Has(tt.wantArg) {
+				t.Errorf("update tidak memuat %q: %v", tt.wantArg, updates[0].Args)
+			}
+			if got := len(fake.Execs("INSERT INTO `transaction_status_logs`")); got != tt.wantLogs {
+				t.Errorf("insert status log = %d, want %d", got, tt.wantLogs)
+			}
+
+			// Pengiriman dipicu di goroutine terpisah
+			select {
+			case got := <-triggered:
+				if !tt.wantTrigger {
+					t.Errorf("pengiriman dipicu untuk %s", got)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tt.wantTrigger {
+					t.Error("pengiriman tidak dipicu setelah settlement")
+				}
+			}
+		})
+	}
+}
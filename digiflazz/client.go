@@ -0,0 +1,199 @@
+// digiflazz/client.go — client API Digiflazz (price list, transaksi, saldo, deposit)
+package digiflazz
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.digiflazz.com"
+
+var ErrInvalidResponse = errors.New("digiflazz: response tidak valid")
+
+// APIError dikembalikan jika Digiflazz menolak request (rc selain sukses/pending),
+// untuk endpoint non-transaksi seperti price list, cek saldo dan deposit
+type APIError struct {
+	StatusCode int
+	RC         string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("digiflazz: rc=%s status=%d: %s", e.RC, e.StatusCode, e.Message)
+}
+
+type Config struct {
+	Username string
+	APIKey   string
+	// Kosong = https://api.digiflazz.com, bisa diarahkan ke server lokal
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+func New(cfg Config) *Client {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 90 * time.Second}
+	}
+	return &Client{cfg: cfg, client: client}
+}
+
+// NewFromEnv membaca DIGIFLAZZ_USERNAME, DIGIFLAZZ_PROD_KEY dan DIGIFLAZZ_BASE_URL
+func NewFromEnv() *Client {
+	return New(Config{
+		Username: os.Getenv("DIGIFLAZZ_USERNAME"),
+		APIKey:   APIKeyFromEnv(),
+		BaseURL:  os.Getenv("DIGIFLAZZ_BASE_URL"),
+	})
+}
+
+// Username dipakai untuk menyusun payload yang disimpan di transaksi
+func (c *Client) Username() string {
+	return c.cfg.Username
+}
+
+// Sign menghasilkan md5(username + api key + suffix). Suffix tergantung endpoint:
+// "pricelist", "depo" (cek saldo), "deposit" atau ref_id untuk transaksi.
+func (c *Client) Sign(suffix string) string {
+	hash := md5.Sum([]byte(c.cfg.Username + c.cfg.APIKey + suffix))
+	return hex.EncodeToString(hash[:])
+}
+
+// envelope adalah bentuk umum response Digiflazz: {"data": ...}
+// data berupa array untuk price list yang sukses, dan object berisi rc/message jika gagal
+type envelope struct {
+	Data json.RawMessage `json:"data"`
+}
+
+type rcData struct {
+	RC      string `json:"rc"`
+	Message string `json:"message"`
+}
+
+// post mengirim payload dan mengembalikan isi field "data" beserta body mentah
+func (c *Client) post(ctx context.Context, path string, payload interface{}) (json.RawMessage, []byte, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("digiflazz: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.BaseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("digiflazz: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("digiflazz: read response: %w", err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil || len(env.Data) == 0 {
+		if resp.StatusCode != http.StatusOK {
+			return nil, body, &APIError{StatusCode: resp.StatusCode, Message: string(body)}
+		}
+		return nil, body, fmt.Errorf("%w: %s", ErrInvalidResponse, string(body))
+	}
+
+	// Selain transaksi, Digiflazz mengirim error sebagai object {rc, message}
+	// dengan HTTP status 400
+	if resp.StatusCode != http.StatusOK && !isTransactionPath(path) {
+		var rc rcData
+		json.Unmarshal(env.Data, &rc)
+		return nil, body, &APIError{StatusCode: resp.StatusCode, RC: rc.RC, Message: rc.Message}
+	}
+
+	return env.Data, body, nil
+}
+
+func isTransactionPath(path string) bool {
+	return path == "/v1/transaction"
+}
+
+// decodeList mengurai data berupa array. Jika data berupa object, itu adalah error.
+func decodeList(data json.RawMessage, v interface{}) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var rc rcData
+		if err := json.Unmarshal(trimmed, &rc); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidResponse, string(trimmed))
+		}
+		return &APIError{StatusCode: http.StatusOK, RC: rc.RC, Message: rc.Message}
+	}
+	if err := json.Unmarshal(trimmed, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// ─── Flexible types ───────────────────────────────────────────────────────────
+
+// FlexString menerima string, angka, maupun null (contoh: stock, cut off)
+type FlexString string
+
+func (s *FlexString) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*s = ""
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(b, &str); err == nil {
+		*s = FlexString(str)
+		return nil
+	}
+	*s = FlexString(strings.Trim(string(b), `"`))
+	return nil
+}
+
+func (s FlexString) String() string {
+	return string(s)
+}
+
+// FlexInt menerima angka maupun angka dalam string, pecahan dibulatkan ke bawah
+type FlexInt int64
+
+func (n *FlexInt) UnmarshalJSON(b []byte) error {
+	raw := strings.Trim(string(b), `"`)
+	if raw == "" || raw == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("digiflazz: invalid number %q", raw)
+	}
+	*n = FlexInt(int64(f))
+	return nil
+}
+
+func (n FlexInt) Int64() int64 {
+	return int64(n)
+}
@@ -0,0 +1,76 @@
+package digiflazz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type Balance struct {
+	Deposit float64 `json:"deposit"`
+}
+
+type balanceRequest struct {
+	Cmd      string `json:"cmd"`
+	Username string `json:"username"`
+	Sign     string `json:"sign"`
+}
+
+// Balance mengecek saldo deposit di Digiflazz
+func (c *Client) Balance(ctx context.Context) (*Balance, error) {
+	data, _, err := c.post(ctx, "/v1/cek-saldo", balanceRequest{
+		Cmd:      "deposit",
+		Username: c.cfg.Username,
+		Sign:     c.Sign("depo"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var balance Balance
+	if err := json.Unmarshal(data, &balance); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return &balance, nil
+}
+
+type DepositRequest struct {
+	Amount    int64  `json:"amount"`
+	Bank      string `json:"Bank"` // BCA | MANDIRI | BRI | BNI
+	OwnerName string `json:"owner_name"`
+}
+
+type depositPayload struct {
+	Username string `json:"username"`
+	DepositRequest
+	Sign string `json:"sign"`
+}
+
+// Deposit berisi nominal yang harus ditransfer (sudah termasuk kode unik)
+// dan berita transfer
+type Deposit struct {
+	RC     string  `json:"rc"`
+	Amount FlexInt `json:"amount"`
+	Notes  string  `json:"notes"`
+}
+
+// Deposit membuat tiket deposit saldo
+func (c *Client) Deposit(ctx context.Context, req DepositRequest) (*Deposit, error) {
+	data, _, err := c.post(ctx, "/v1/deposit", depositPayload{
+		Username:       c.cfg.Username,
+		DepositRequest: req,
+		Sign:           c.Sign("deposit"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deposit Deposit
+	if err := json.Unmarshal(data, &deposit); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if deposit.RC != "" && deposit.RC != RCSuccess {
+		return nil, &APIError{StatusCode: 200, RC: deposit.RC, Message: deposit.Notes}
+	}
+	return &deposit, nil
+}
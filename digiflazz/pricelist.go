@@ -0,0 +1,75 @@
+package digiflazz
+
+import "context"
+
+const (
+	CmdPrepaid = "prepaid"
+	CmdPasca   = "pasca"
+)
+
+type PrepaidProduct struct {
+	ProductName         string     `json:"product_name"`
+	Category            string     `json:"category"`
+	Brand               string     `json:"brand"`
+	Type                string     `json:"type"`
+	SellerName          string     `json:"seller_name"`
+	Price               FlexInt    `json:"price"`
+	BuyerSkuCode        string     `json:"buyer_sku_code"`
+	BuyerProductStatus  bool       `json:"buyer_product_status"`
+	SellerProductStatus bool       `json:"seller_product_status"`
+	UnlimitedStock      bool       `json:"unlimited_stock"`
+	Stock               FlexString `json:"stock"`
+	Multi               bool       `json:"multi"`
+	StartCutOff         FlexString `json:"start_cut_off"`
+	EndCutOff           FlexString `json:"end_cut_off"`
+	Desc                string     `json:"desc"`
+}
+
+type PostpaidProduct struct {
+	ProductName         string  `json:"product_name"`
+	Category            string  `json:"category"`
+	Brand               string  `json:"brand"`
+	SellerName          string  `json:"seller_name"`
+	Admin               FlexInt `json:"admin"`
+	Commission          FlexInt `json:"commission"`
+	BuyerSkuCode        string  `json:"buyer_sku_code"`
+	BuyerProductStatus  bool    `json:"buyer_product_status"`
+	SellerProductStatus bool    `json:"seller_product_status"`
+	Desc                string  `json:"desc"`
+}
+
+type priceListRequest struct {
+	Cmd      string `json:"cmd"`
+	Username string `json:"username"`
+	Sign     string `json:"sign"`
+}
+
+// PriceListPrepaid mengambil daftar produk prabayar
+func (c *Client) PriceListPrepaid(ctx context.Context) ([]PrepaidProduct, error) {
+	var products []PrepaidProduct
+	if err := c.priceList(ctx, CmdPrepaid, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// PriceListPasca mengambil daftar produk pascabayar
+func (c *Client) PriceListPasca(ctx context.Context) ([]PostpaidProduct, error) {
+	var products []PostpaidProduct
+	if err := c.priceList(ctx, CmdPasca, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (c *Client) priceList(ctx context.Context, cmd string, v interface{}) error {
+	data, _, err := c.post(ctx, "/v1/price-list", priceListRequest{
+		Cmd:      cmd,
+		Username: c.cfg.Username,
+		Sign:     c.Sign("pricelist"),
+	})
+	if err != nil {
+		return err
+	}
+	return decodeList(data, v)
+}
@@ -0,0 +1,50 @@
+// digiflazz/testing.go — mode testing Digiflazz (testing: true, tanpa memotong deposit)
+package digiflazz
+
+import (
+	"os"
+	"strconv"
+)
+
+// Nomor pelanggan uji prabayar dari dokumentasi Digiflazz
+const (
+	TestCustomerSuccess        = "087800001230"
+	TestCustomerFailed         = "087800001232"
+	TestCustomerPendingSuccess = "087800001233"
+	TestCustomerPendingFailed  = "087800001234"
+)
+
+var testCustomers = map[string]bool{
+	TestCustomerSuccess:        true,
+	TestCustomerFailed:         true,
+	TestCustomerPendingSuccess: true,
+	TestCustomerPendingFailed:  true,
+}
+
+// TestingEnabled true jika DIGIFLAZZ_TESTING aktif
+func TestingEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("DIGIFLAZZ_TESTING"))
+	return enabled
+}
+
+// APIKeyFromEnv mengembalikan DIGIFLAZZ_DEV_KEY saat mode testing (jika diisi),
+// selain itu DIGIFLAZZ_PROD_KEY
+func APIKeyFromEnv() string {
+	if key := os.Getenv("DIGIFLAZZ_DEV_KEY"); key != "" && TestingEnabled() {
+		return key
+	}
+	return os.Getenv("DIGIFLAZZ_PROD_KEY")
+}
+
+// TestCustomerNo memetakan nomor pelanggan ke nomor uji. Nomor uji dibiarkan
+// supaya skenario (sukses, gagal, pending) bisa dipilih dari checkout,
+// nomor lain diganti DIGIFLAZZ_TEST_CUSTOMER_NO atau TestCustomerSuccess.
+func TestCustomerNo(customerNo string) string {
+	if testCustomers[customerNo] {
+		return customerNo
+	}
+	if v := os.Getenv("DIGIFLAZZ_TEST_CUSTOMER_NO"); v != "" {
+		return v
+	}
+	return TestCustomerSuccess
+}
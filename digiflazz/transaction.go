@@ -0,0 +1,124 @@
+package digiflazz
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	StatusSukses  = "Sukses"
+	StatusPending = "Pending"
+	StatusGagal   = "Gagal"
+
+	RCSuccess = "00"
+	RCPending = "03"
+
+	cmdInquiryPasca = "inq-pasca"
+	cmdPayPasca     = "pay-pasca"
+	cmdStatusPasca  = "status-pasca"
+)
+
+// TransactionRequest dipakai untuk topup prabayar maupun inquiry/bayar pascabayar.
+// ref_id harus unik per transaksi, mengirim ulang ref_id yang sama = cek status.
+type TransactionRequest struct {
+	BuyerSkuCode string `json:"buyer_sku_code"`
+	CustomerNo   string `json:"customer_no"`
+	RefID        string `json:"ref_id"`
+	Testing      bool   `json:"testing,omitempty"`
+	Msg          string `json:"msg,omitempty"`
+}
+
+type transactionPayload struct {
+	Username string `json:"username"`
+	Commands string `json:"commands,omitempty"`
+	TransactionRequest
+	Sign string `json:"sign"`
+}
+
+// Transaction adalah data transaksi dari response maupun callback Digiflazz
+type Transaction struct {
+	RefID          string  `json:"ref_id"`
+	TrxID          string  `json:"trx_id"`
+	CustomerNo     string  `json:"customer_no"`
+	CustomerName   string  `json:"customer_name"`
+	BuyerSkuCode   string  `json:"buyer_sku_code"`
+	Message        string  `json:"message"`
+	Status         string  `json:"status"`
+	RC             string  `json:"rc"`
+	SN             string  `json:"sn"`
+	BuyerLastSaldo float64 `json:"buyer_last_saldo"`
+	Price          FlexInt `json:"price"`
+	SellingPrice   FlexInt `json:"selling_price"`
+	Admin          FlexInt `json:"admin"`
+	Tele           string  `json:"tele"`
+	WA             string  `json:"wa"`
+
+	// Rincian tagihan pascabayar, isinya berbeda per produk
+	Desc json.RawMessage `json:"desc,omitempty"`
+
+	// Body response mentah, untuk disimpan ke transaksi
+	Raw json.RawMessage `json:"-"`
+}
+
+func (t *Transaction) IsSuccess() bool {
+	return t.RC == RCSuccess || t.Status == StatusSukses
+}
+
+func (t *Transaction) IsPending() bool {
+	return t.Status == StatusPending
+}
+
+func (t *Transaction) IsFailed() bool {
+	return t.Status == StatusGagal
+}
+
+// Topup mengirim transaksi prabayar
+func (c *Client) Topup(ctx context.Context, req TransactionRequest) (*Transaction, error) {
+	return c.transaction(ctx, "", req)
+}
+
+// Status mengecek status transaksi prabayar dengan mengirim ulang ref_id yang sama
+func (c *Client) Status(ctx context.Context, req TransactionRequest) (*Transaction, error) {
+	return c.transaction(ctx, "", req)
+}
+
+// InquiryPasca mengecek tagihan pascabayar
+func (c *Client) InquiryPasca(ctx context.Context, req TransactionRequest) (*Transaction, error) {
+	return c.transaction(ctx, cmdInquiryPasca, req)
+}
+
+// PayPasca membayar tagihan pascabayar, ref_id harus sama dengan saat inquiry
+func (c *Client) PayPasca(ctx context.Context, req TransactionRequest) (*Transaction, error) {
+	return c.transaction(ctx, cmdPayPasca, req)
+}
+
+// StatusPasca mengecek status pembayaran pascabayar
+func (c *Client) StatusPasca(ctx context.Context, req TransactionRequest) (*Transaction, error) {
+	return c.transaction(ctx, cmdStatusPasca, req)
+}
+
+// transaction tidak menganggap rc gagal sebagai error, pemanggil memutuskan
+// berdasarkan RC & Status. Error hanya untuk gangguan koneksi/response rusak.
+func (c *Client) transaction(ctx context.Context, commands string, req TransactionRequest) (*Transaction, error) {
+	if req.RefID == "" {
+		return nil, fmt.Errorf("digiflazz: ref_id wajib diisi")
+	}
+
+	data, body, err := c.post(ctx, "/v1/transaction", transactionPayload{
+		Username:           c.cfg.Username,
+		Commands:           commands,
+		TransactionRequest: req,
+		Sign:               c.Sign(req.RefID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var trx Transaction
+	if err := json.Unmarshal(data, &trx); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	trx.Raw = body
+	return &trx, nil
+}
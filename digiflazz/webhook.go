@@ -0,0 +1,103 @@
+package digiflazz
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Event yang dikirim Digiflazz di header X-Digiflazz-Event
+const (
+	EventCreate = "create"
+	EventUpdate = "update"
+)
+
+var (
+	ErrWebhookSecretMissing = errors.New("digiflazz: DIGIFLAZZ_WEBHOOK_SECRET belum diset")
+	ErrMissingSignature     = errors.New("digiflazz: header X-Hub-Signature kosong")
+	ErrInvalidSignature     = errors.New("digiflazz: signature webhook tidak valid")
+	ErrUnknownEvent         = errors.New("digiflazz: event webhook tidak dikenal")
+	ErrIPNotAllowed         = errors.New("digiflazz: IP pengirim webhook tidak diizinkan")
+)
+
+// WebhookVerifier memvalidasi webhook Digiflazz: X-Hub-Signature berisi
+// "sha1=" + HMAC-SHA1 body dengan secret webhook
+type WebhookVerifier struct {
+	Secret string
+	// Kosong = semua IP diizinkan
+	AllowedIPs []string
+}
+
+// NewWebhookVerifierFromEnv membaca DIGIFLAZZ_WEBHOOK_SECRET dan
+// DIGIFLAZZ_WEBHOOK_IPS (dipisah koma)
+func NewWebhookVerifierFromEnv() *WebhookVerifier {
+	var ips []string
+	for _, ip := range strings.Split(os.Getenv("DIGIFLAZZ_WEBHOOK_IPS"), ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return &WebhookVerifier{
+		Secret:     os.Getenv("DIGIFLAZZ_WEBHOOK_SECRET"),
+		AllowedIPs: ips,
+	}
+}
+
+// Verify mengecek IP pengirim, event dan signature. Tanpa secret semua webhook ditolak.
+func (v *WebhookVerifier) Verify(body []byte, header http.Header, remoteIP string) error {
+	if v.Secret == "" {
+		return ErrWebhookSecretMissing
+	}
+
+	if len(v.AllowedIPs) > 0 && !v.ipAllowed(remoteIP) {
+		return ErrIPNotAllowed
+	}
+
+	signature := header.Get("X-Hub-Signature")
+	if signature == "" {
+		return ErrMissingSignature
+	}
+	if !hmac.Equal([]byte(v.Sign(body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	// Header event opsional, tapi jika ada harus create/update
+	if event := header.Get("X-Digiflazz-Event"); event != "" && event != EventCreate && event != EventUpdate {
+		return ErrUnknownEvent
+	}
+	return nil
+}
+
+// Sign menghasilkan nilai X-Hub-Signature untuk body
+func (v *WebhookVerifier) Sign(body []byte) string {
+	mac := hmac.New(sha1.New, []byte(v.Secret))
+	mac.Write(body)
+	return "sha1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (v *WebhookVerifier) ipAllowed(remoteIP string) bool {
+	for _, ip := range v.AllowedIPs {
+		if ip == remoteIP {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWebhook mengurai body webhook {"data": {...}} menjadi Transaction
+func ParseWebhook(body []byte) (*Transaction, error) {
+	var payload struct {
+		Data Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	payload.Data.Raw = body
+	return &payload.Data, nil
+}
@@ -0,0 +1,32 @@
+// jobs/balance_check.go — cek saldo Digiflazz secara berkala
+package jobs
+
+import (
+	"api-arveshop-go/balance"
+	"api-arveshop-go/digiflazz"
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskBalanceCheck = "balance:check"
+
+type BalanceChecker struct {
+	db     *gorm.DB
+	client *digiflazz.Client
+}
+
+func NewBalanceChecker(db *gorm.DB, client *digiflazz.Client) *BalanceChecker {
+	return &BalanceChecker{db: db, client: client}
+}
+
+func NewBalanceCheckTask() *asynq.Task {
+	return asynq.NewTask(TaskBalanceCheck, nil, asynq.MaxRetry(0), asynq.Timeout(time.Minute))
+}
+
+func (b *BalanceChecker) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	_, err := balance.Check(ctx, b.db, b.client, balance.SourceScheduled)
+	return err
+}
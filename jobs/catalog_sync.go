@@ -0,0 +1,37 @@
+// jobs/catalog_sync.go — sinkronisasi katalog prabayar Digiflazz secara berkala
+package jobs
+
+import (
+	"api-arveshop-go/catalog"
+	"api-arveshop-go/digiflazz"
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskCatalogSync = "catalog:sync"
+
+type CatalogSyncer struct {
+	db     *gorm.DB
+	client *digiflazz.Client
+}
+
+func NewCatalogSyncer(db *gorm.DB, client *digiflazz.Client) *CatalogSyncer {
+	return &CatalogSyncer{db: db, client: client}
+}
+
+// NewCatalogSyncTask dibuat unik supaya sync tidak berjalan bertumpuk
+func NewCatalogSyncTask() *asynq.Task {
+	return asynq.NewTask(TaskCatalogSync, nil,
+		asynq.MaxRetry(0),
+		asynq.Timeout(10*time.Minute),
+		asynq.Unique(10*time.Minute),
+	)
+}
+
+func (s *CatalogSyncer) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	_, err := catalog.SyncPrepaid(ctx, s.db, s.client, catalog.TriggerScheduled)
+	return err
+}
@@ -2,18 +2,22 @@
 package jobs
 
 import (
+	"api-arveshop-go/digiflazz"
 	"api-arveshop-go/models"
-	"bytes"
+	"api-arveshop-go/refund"
+	"api-arveshop-go/supplier"
+	"api-arveshop-go/txstate"
+	"api-arveshop-go/websocket"
 	"context"
-	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/hibiken/asynq"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
@@ -29,7 +33,7 @@ type DigiflazzTopupPayload struct {
 type DigiflazzConfig struct {
 	Username string
 	ProdKey  string
-	BaseURL  string
+	BaseURL  string // kosong = https://api.digiflazz.com
 }
 
 // ─── Job ──────────────────────────────────────────────────────────────────────
@@ -39,6 +43,9 @@ type DigiflazzTopupJob struct {
 	db      *gorm.DB
 	rdb     *redis.Client
 	cfg     DigiflazzConfig
+	client  *digiflazz.Client
+	source  string        // sumber perubahan status untuk txstate
+	queue   *asynq.Client // opsional, untuk menjadwalkan ulang order
 
 	maxRetries int
 	backoff    []time.Duration
@@ -50,11 +57,23 @@ func NewDigiflazzTopupJob(orderID uint, db *gorm.DB, rdb *redis.Client, cfg Digi
 		db:         db,
 		rdb:        rdb,
 		cfg:        cfg,
+		client: digiflazz.New(digiflazz.Config{
+			Username: cfg.Username,
+			APIKey:   cfg.ProdKey,
+			BaseURL:  cfg.BaseURL,
+		}),
+		source:     txstate.SourceTopupJob,
 		maxRetries: 5,
 		backoff:    []time.Duration{60, 180, 300, 600, 900},
 	}
 }
 
+// WithQueue memasang asynq client supaya order bisa dijadwalkan ulang langsung
+func (j *DigiflazzTopupJob) WithQueue(queue *asynq.Client) *DigiflazzTopupJob {
+	j.queue = queue
+	return j
+}
+
 // Handle adalah entry point job, dipanggil oleh worker
 func (j *DigiflazzTopupJob) Handle(ctx context.Context) error {
 	// Load & lock order
@@ -66,8 +85,14 @@ func (j *DigiflazzTopupJob) Handle(ctx context.Context) error {
 		return nil // jangan retry kalau order tidak ada
 	}
 
-	// Cek apakah sudah sukses
-	if order.DigiflazzStatus != nil && (*order.DigiflazzStatus == "Sukses" || *order.DigiflazzStatus == "cancelled") {
+	// Cek apakah sudah selesai (sukses, gagal final atau dibatalkan)
+	if txstate.IsFinalFulfilment(order.DigiflazzStatus) {
+		return nil
+	}
+
+	// Task lama untuk order yang sudah dikirim & menunggu callback
+	if awaitingSupplier(&order) {
+		slog.Info("Order sudah dikirim, menunggu callback", "order_id", order.OrderID)
 		return nil
 	}
 
@@ -81,63 +106,195 @@ func (j *DigiflazzTopupJob) Handle(ctx context.Context) error {
 	defer lock.Release(ctx)
 
 	if err := j.processTopup(ctx, &order); err != nil {
+		if errors.Is(err, txstate.ErrIllegalTransition) {
+			slog.Warn("Perubahan status ditolak", "order_id", order.OrderID, "err", err)
+			return nil
+		}
 		return j.handleException(ctx, &order, err)
 	}
 
 	return nil
 }
 
+// HandleCallback menerapkan hasil dari webhook Digiflazz dengan logika yang sama
+// seperti response API: sukses, pending, atau gagal (fallback ke kandidat lain,
+// atau refund saldo + refund customer). Callback berulang aman karena refund
+// saldo & refund customer idempotent. Callback untuk percobaan lama hanya dicatat.
+func (j *DigiflazzTopupJob) HandleCallback(ctx context.Context, trx *digiflazz.Transaction) error {
+	j.source = txstate.SourceDigiflazzWebhook
+
+	var order models.Transaction
+	if err := j.db.WithContext(ctx).First(&order, j.OrderID).Error; err != nil {
+		return err
+	}
+
+	j.db.Model(&order).Update("digiflazz_callback", []byte(trx.Raw))
+
+	result := supplier.FromDigiflazz(trx)
+	// Callback adalah status akhir untuk ref_id tersebut, tidak ada yang bisa di-retry
+	if result.Outcome == supplier.OutcomeRetryable {
+		result.Outcome = supplier.OutcomeFailed
+	}
+
+	slog.Info("Callback dari Digiflazz", "order_id", order.OrderID, "ref_id", trx.RefID, "status", trx.Status, "rc", trx.RC)
+
+	current, err := j.currentAttempt(ctx, &order)
+	if err != nil {
+		return err
+	}
+	if current.RefID != trx.RefID {
+		var stale models.FulfilmentAttempt
+		if err := j.db.WithContext(ctx).Where("ref_id = ? AND transaction_id = ?", trx.RefID, order.ID).First(&stale).Error; err == nil {
+			j.recordAttempt(&stale, result)
+		}
+		slog.Warn("Callback untuk percobaan lama, status order tidak diubah", "order_id", order.OrderID, "ref_id", trx.RefID, "current_ref_id", current.RefID)
+		return nil
+	}
+
+	err = j.handleAPIResponse(ctx, &order, current, result)
+
+	// Callback yang tidak sesuai urutan cukup dicatat, jangan buat Digiflazz retry
+	if errors.Is(err, txstate.ErrIllegalTransition) {
+		slog.Warn("Callback diabaikan", "order_id", order.OrderID, "err", err)
+		return nil
+	}
+	return err
+}
+
+// CheckStatus menanyakan ulang order pending ke supplier. Request dikirim
+// dengan ref_id yang sama sehingga diperlakukan sebagai cek status,
+// hasilnya diterapkan lewat handleAPIResponse seperti response biasa.
+func (j *DigiflazzTopupJob) CheckStatus(ctx context.Context) error {
+	j.source = txstate.SourceStatusCheck
+
+	var order models.Transaction
+	if err := j.db.WithContext(ctx).First(&order, j.OrderID).Error; err != nil {
+		return err
+	}
+	if order.DigiflazzSentAt == nil || order.DigiflazzStatus == nil || *order.DigiflazzStatus != txstate.FulfilmentPending {
+		return nil
+	}
+
+	// Jangan bentrok dengan job topup yang sedang berjalan
+	lock, err := j.acquireLock(ctx, fmt.Sprintf("digiflazz_topup_%s", order.OrderID), 60*time.Second)
+	if err != nil {
+		return nil
+	}
+	defer lock.Release(ctx)
+
+	attempt, err := j.currentAttempt(ctx, &order)
+	if err != nil {
+		return err
+	}
+	sup, err := j.supplierFor(attempt.Provider)
+	if err != nil {
+		return err
+	}
+
+	httpCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := sup.Status(httpCtx, j.buildRequest(&order, attempt))
+	if err != nil {
+		return fmt.Errorf("%s status check: %w", attempt.Provider, err)
+	}
+
+	j.db.Model(&order).Update("digiflazz_response", result.Raw)
+
+	err = j.handleAPIResponse(ctx, &order, attempt, result)
+	if errors.Is(err, txstate.ErrIllegalTransition) {
+		slog.Warn("Hasil cek status diabaikan", "order_id", order.OrderID, "err", err)
+		return nil
+	}
+	return err
+}
+
 func (j *DigiflazzTopupJob) processTopup(ctx context.Context, order *models.Transaction) error {
-	// Ambil data produk untuk cek cutoff
-	var product models.Product
-	productErr := j.db.First(&product, order.ProductID).Error
-	if productErr == nil {
-		// Cek cutoff
-		if product.IsWithinCutoff() {
-			statusMsg := "Produk sedang cutoff"
-			retryAt := time.Now().Add(10 * time.Minute)
-			return j.db.Model(order).Updates(map[string]any{
-				"digiflazz_status": "pending",
-				"status_message":   &statusMsg,
-				"retry_at":         &retryAt,
-			}).Error
+	// Ambil data produk untuk cek cutoff, tagihan pascabayar tidak punya cutoff
+	if !isPostpaid(order) {
+		var product models.Product
+		productErr := gorm.ErrRecordNotFound
+		if order.ProductID != nil {
+			productErr = j.db.First(&product, *order.ProductID).Error
+		}
+		if productErr == nil {
+			// Cek cutoff
+			if product.IsWithinCutoff() {
+				return j.scheduleAfterCutoff(ctx, order, &product)
+			}
+		} else {
+			slog.Warn("Product not found", "product_id", order.ProductID, "err", productErr)
 		}
-	} else {
-		slog.Warn("Product not found", "product_id", order.ProductID, "err", productErr)
 	}
 
 	// Debit saldo sekali saja
 	if order.SaldoDebitedAt == nil {
-		if err := j.debitSaldo(ctx, order); err != nil {
+		err := j.debitSaldo(ctx, order)
+		// Pembayaran sudah settlement, order gagal lewat handleFailed supaya dana pembeli dikembalikan
+		if errors.Is(err, errNoProfil) {
+			return j.handleFailed(ctx, order, "Konfigurasi aplikasi tidak ditemukan", "NOPROF")
+		}
+		if errors.Is(err, errInsufficientSaldo) {
+			return j.handleFailed(ctx, order, "Saldo aplikasi tidak mencukupi", "INSUFF")
+		}
+		if err != nil {
 			return err
 		}
 		// Reload setelah update
 		if err := j.db.First(order, order.ID).Error; err != nil {
 			return err
 		}
-		// Kalau debit gagal, order sudah diset failed
-		if order.DigiflazzStatus != nil && *order.DigiflazzStatus == "failed" {
-			return nil
+	}
+
+	return j.sendToSupplier(ctx, order)
+}
+
+// scheduleAfterCutoff menunda order sampai produk keluar dari jam cutoff.
+// Tanpa queue (dipanggil langsung dari webhook), order diantrikan oleh
+// TopupRetryScheduler berdasarkan retry_at yang sama.
+func (j *DigiflazzTopupJob) scheduleAfterCutoff(ctx context.Context, order *models.Transaction, product *models.Product) error {
+	retryAt := product.NextProcessTime()
+
+	statusMsg := fmt.Sprintf("Produk sedang cutoff, diproses pukul %s", retryAt.Format("15:04"))
+	if err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, statusMsg, map[string]any{
+		"retry_at": &retryAt,
+	}); err != nil {
+		return err
+	}
+
+	if j.queue != nil {
+		if _, err := EnqueueTopupAt(ctx, j.queue, order.ID, retryAt); err != nil {
+			slog.Error("Gagal menjadwalkan order cutoff", "order_id", order.OrderID, "err", err)
 		}
 	}
 
-	return j.hitDigiflazzAPI(ctx, order)
+	slog.Info("🕒 Order ditunda karena cutoff", "order_id", order.OrderID, "process_at", retryAt)
+	websocket.BroadcastOrderScheduled(order.OrderID, retryAt, statusMsg)
+	return nil
 }
 
 // ─── Saldo ────────────────────────────────────────────────────────────────────
 
+var (
+	// errInsufficientSaldo saldo aplikasi tidak cukup untuk harga beli / selisih harga kandidat fallback
+	errInsufficientSaldo = errors.New("saldo aplikasi tidak mencukupi")
+	errNoProfil          = errors.New("konfigurasi aplikasi tidak ditemukan")
+)
+
 func (j *DigiflazzTopupJob) debitSaldo(ctx context.Context, order *models.Transaction) error {
+	// Order uji tidak memotong saldo, saldo_debited_at tetap kosong sehingga tidak ada refund saldo
+	if order.IsTest {
+		return j.transition(ctx, j.db, order, txstate.FulfilmentProcessing, "Mode testing, saldo tidak dipotong", nil)
+	}
+
 	return j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var profil models.ProfilAplikasi
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&profil).Error; err != nil {
-			slog.Error("ProfilAplikasi tidak ditemukan")
-			statusMsg := "Konfigurasi aplikasi tidak ditemukan"
-			lastErr := "NOPROF"
-			return tx.Model(order).Updates(map[string]any{
-				"digiflazz_status": "failed",
-				"status_message":   &statusMsg,
-				"last_error_code":  &lastErr,
-			}).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.Error("ProfilAplikasi tidak ditemukan")
+				return errNoProfil
+			}
+			return err
 		}
 
 		// Konversi PurchasePrice (decimal.Decimal) ke float64
@@ -148,13 +305,7 @@ func (j *DigiflazzTopupJob) debitSaldo(ctx context.Context, order *models.Transa
 				"saldo_tersedia", profil.Saldo,
 				"saldo_dibutuhkan", purchasePrice,
 			)
-			statusMsg := "Saldo aplikasi tidak mencukupi"
-			lastErr := "INSUFF"
-			return tx.Model(order).Updates(map[string]any{
-				"digiflazz_status": "failed",
-				"status_message":   &statusMsg,
-				"last_error_code":  &lastErr,
-			}).Error
+			return errInsufficientSaldo
 		}
 
 		saldoSebelum := profil.Saldo
@@ -169,30 +320,48 @@ func (j *DigiflazzTopupJob) debitSaldo(ctx context.Context, order *models.Transa
 		)
 
 		now := time.Now()
-		statusMsg := "Saldo dipotong, memproses transaksi..."
-		return tx.Model(order).Updates(map[string]any{
+		return j.transition(ctx, tx, order, txstate.FulfilmentProcessing, "Saldo dipotong, memproses transaksi...", map[string]any{
 			"saldo_debited_at": &now,
-			"digiflazz_status": "processing",
-			"status_message":   &statusMsg,
-		}).Error
+		})
 	})
 }
 
-func (j *DigiflazzTopupJob) refundSaldo(ctx context.Context, order *models.Transaction) error {
-	return j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// refundSaldo mengembalikan harga beli ke saldo aplikasi tepat satu kali.
+// saldo_refunded_at dikunci bersama baris transaksi, sehingga job dan callback
+// yang datang bersamaan tidak bisa mengembalikan saldo dua kali.
+func (j *DigiflazzTopupJob) refundSaldo(ctx context.Context, db *gorm.DB, order *models.Transaction) (bool, error) {
+	refunded := false
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "saldo_debited_at", "saldo_refunded_at", "purchase_price").
+			First(&locked, order.ID).Error; err != nil {
+			return err
+		}
+		if locked.SaldoDebitedAt == nil || locked.SaldoRefundedAt != nil {
+			return nil
+		}
+
 		var profil models.ProfilAplikasi
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&profil).Error; err != nil {
 			slog.Error("ProfilAplikasi tidak ditemukan untuk refund")
 			return nil
 		}
 
-		purchasePrice, _ := order.PurchasePrice.Float64()
+		purchasePrice, _ := locked.PurchasePrice.Float64()
 		saldoSebelum := profil.Saldo
-		
+
 		if err := tx.Model(&profil).UpdateColumn("saldo", gorm.Expr("saldo + ?", purchasePrice)).Error; err != nil {
 			return err
 		}
 
+		now := time.Now()
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", locked.ID).Update("saldo_refunded_at", &now).Error; err != nil {
+			return err
+		}
+		order.SaldoRefundedAt = &now
+		refunded = true
+
 		slog.Info("Saldo dikembalikan",
 			"order_id", order.OrderID,
 			"saldo_sebelum", saldoSebelum,
@@ -200,138 +369,332 @@ func (j *DigiflazzTopupJob) refundSaldo(ctx context.Context, order *models.Trans
 		)
 		return nil
 	})
+	return refunded, err
 }
 
-// ─── API ──────────────────────────────────────────────────────────────────────
+// ─── Supplier ─────────────────────────────────────────────────────────────────
 
-type digiflazzPayload struct {
-	Username     string `json:"username"`
-	BuyerSkuCode string `json:"buyer_sku_code"`
-	CustomerNo   string `json:"customer_no"`
-	RefID        string `json:"ref_id"`
-	Sign         string `json:"sign"`
+// supplierFor mengambil supplier untuk provider, Digiflazz memakai client job ini
+func (j *DigiflazzTopupJob) supplierFor(provider string) (supplier.Supplier, error) {
+	if provider == "" || provider == supplier.DefaultProvider {
+		return supplier.NewDigiflazz(j.client), nil
+	}
+	return supplier.Get(provider)
 }
 
-type digiflazzResponseData struct {
-	RC      string `json:"rc"`
-	Message string `json:"message"`
-	SN      string `json:"sn"`
-	RefID   string `json:"ref_id"`
+// currentAttempt mengambil percobaan terakhir, percobaan pertama dibuat dari
+// kandidat teratas jika order belum pernah dikirim
+func (j *DigiflazzTopupJob) currentAttempt(ctx context.Context, order *models.Transaction) (*models.FulfilmentAttempt, error) {
+	var attempt models.FulfilmentAttempt
+	err := j.db.WithContext(ctx).Where("transaction_id = ?", order.ID).Order("attempt DESC").First(&attempt).Error
+	if err == nil {
+		return &attempt, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	candidates, err := supplier.Candidates(ctx, j.db, order)
+	if err != nil {
+		return nil, err
+	}
+	return j.startAttempt(ctx, order, 1, 0, candidates[0])
 }
 
-type digiflazzResponse struct {
-	Data digiflazzResponseData `json:"data"`
+// startAttempt mencatat percobaan baru. Percobaan pertama memakai order_id
+// sebagai ref_id (sama dengan ref_id inquiry pascabayar), berikutnya order_id-n.
+func (j *DigiflazzTopupJob) startAttempt(ctx context.Context, order *models.Transaction, n, candidate int, c supplier.Candidate) (*models.FulfilmentAttempt, error) {
+	refID := order.OrderID
+	if n > 1 {
+		refID = fmt.Sprintf("%s-%d", order.OrderID, n)
+	}
+
+	attempt := models.FulfilmentAttempt{
+		TransactionID: order.ID,
+		OrderID:       order.OrderID,
+		Attempt:       n,
+		Candidate:     candidate,
+		Provider:      c.Provider,
+		SKU:           c.SKU,
+		RefID:         refID,
+		PurchasePrice: order.PurchasePrice,
+		Status:        supplier.OutcomePending,
+	}
+	if err := j.db.WithContext(ctx).Create(&attempt).Error; err != nil {
+		return nil, err
+	}
+
+	order.RefID = &refID
+	j.db.Model(&models.Transaction{}).Where("id = ?", order.ID).Update("ref_id", &refID)
+	return &attempt, nil
 }
 
-func (j *DigiflazzTopupJob) hitDigiflazzAPI(ctx context.Context, order *models.Transaction) error {
-	payload := j.buildPayload(order)
+// recordAttempt menyimpan hasil terakhir dari supplier pada percobaan
+func (j *DigiflazzTopupJob) recordAttempt(attempt *models.FulfilmentAttempt, result *supplier.Result) {
+	status := supplier.OutcomePending
+	switch {
+	case result.Outcome == supplier.OutcomeSuccess:
+		status = supplier.OutcomeSuccess
+	case result.ShouldFallback():
+		status = supplier.OutcomeFailed
+	}
 
-	slog.Info("Mengirim request ke Digiflazz", "order_id", order.OrderID)
+	attempt.Status = status
+	j.db.Model(attempt).Updates(map[string]any{
+		"status":   status,
+		"rc":       &result.RC,
+		"message":  &result.Message,
+		"response": result.Raw,
+	})
+}
 
-	now := time.Now()
-	j.db.Model(order).Update("digiflazz_sent_at", &now)
+func (j *DigiflazzTopupJob) sendToSupplier(ctx context.Context, order *models.Transaction) error {
+	attempt, err := j.currentAttempt(ctx, order)
+	if err != nil {
+		return err
+	}
+	sup, err := j.supplierFor(attempt.Provider)
+	if err != nil {
+		return err
+	}
+	request := j.buildRequest(order, attempt)
 
-	payloadJSON, _ := json.Marshal(payload)
+	slog.Info("Mengirim request ke supplier", "order_id", order.OrderID, "provider", attempt.Provider, "sku", attempt.SKU, "attempt", attempt.Attempt)
+
+	// retry_at dikosongkan bersama sent_at supaya jadwal yang sudah dipakai
+	// tidak diambil lagi oleh TopupRetryScheduler
+	now := time.Now()
+	j.db.Model(order).Updates(map[string]any{
+		"digiflazz_sent_at": &now,
+		"retry_at":          nil,
+	})
+	order.DigiflazzSentAt = &now
+	order.RetryAt = nil
 
 	// Tentukan timeout berdasarkan produk
 	var timeout time.Duration = 30 * time.Second
 	var product models.Product
-	if err := j.db.First(&product, order.ProductID).Error; err == nil {
+	if isPostpaid(order) {
+		timeout = 60 * time.Second
+	} else if order.ProductID != nil && j.db.First(&product, *order.ProductID).Error == nil {
 		timeout = j.getAPITimeout(&product)
 	}
 
 	httpCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	resp, err := j.doHTTPPost(httpCtx, j.cfg.BaseURL+"/v1/transaction", payloadJSON)
-	if err != nil {
-		return fmt.Errorf("http error: %w", err)
-	}
+	result, err := sup.Topup(httpCtx, request)
 
 	// Simpan request & response ke DB
-	j.db.Model(order).Updates(map[string]any{
-		"digiflazz_request":  payloadJSON,
-		"digiflazz_response": resp,
-	})
+	requestJSON, _ := json.Marshal(request)
+	updates := map[string]any{"digiflazz_request": requestJSON}
+	if result != nil {
+		updates["digiflazz_response"] = result.Raw
+	}
+	j.db.Model(order).Updates(updates)
 
-	var apiResp digiflazzResponse
-	if err := json.Unmarshal(resp, &apiResp); err != nil {
-		return fmt.Errorf("unmarshal response: %w", err)
+	if err != nil {
+		return fmt.Errorf("%s error: %w", attempt.Provider, err)
 	}
 
-	return j.handleAPIResponse(ctx, order, apiResp.Data)
+	return j.handleAPIResponse(ctx, order, attempt, result)
 }
 
-func (j *DigiflazzTopupJob) handleAPIResponse(ctx context.Context, order *models.Transaction, data digiflazzResponseData) error {
-	rc := data.RC
-	message := data.Message
+func (j *DigiflazzTopupJob) handleAPIResponse(ctx context.Context, order *models.Transaction, attempt *models.FulfilmentAttempt, result *supplier.Result) error {
+	rc := result.RC
+	message := result.Message
 	if message == "" {
 		message = "Unknown response"
 	}
 
-	slog.Info("Response dari Digiflazz", "order_id", order.OrderID, "rc", rc, "message", message)
+	slog.Info("Response dari supplier", "order_id", order.OrderID, "provider", attempt.Provider, "rc", rc, "outcome", result.Outcome, "message", message)
 
-	switch rc {
-	case "00":
-		return j.handleSuccess(order, data)
-	case "201":
-		return j.handlePending(order, message)
-	case "40", "41", "42", "43", "44", "45":
-		return j.handleFailed(ctx, order, message, rc)
-	case "06", "07", "08", "17", "39":
+	j.recordAttempt(attempt, result)
+
+	switch result.Outcome {
+	case supplier.OutcomeSuccess:
+		return j.handleSuccess(ctx, order, result)
+	case supplier.OutcomePending:
+		return j.handlePending(ctx, order, message)
+	case supplier.OutcomeFailed, supplier.OutcomeUnavailable:
+		return j.handleFallback(ctx, order, attempt, message, rc)
+	case supplier.OutcomeRetryable:
 		return j.handleRetryable(ctx, order, message, rc)
 	default:
-		return j.handleUnknown(order, message, rc)
+		return j.handleUnknown(ctx, order, message, rc)
 	}
 }
 
-func (j *DigiflazzTopupJob) handleSuccess(order *models.Transaction, data digiflazzResponseData) error {
-	status := "Sukses"
-	err := j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   "Transaksi berhasil",
-		"serial_number":    &data.SN,
-		"ref_id":           &data.RefID,
-	}).Error
+func (j *DigiflazzTopupJob) handleSuccess(ctx context.Context, order *models.Transaction, result *supplier.Result) error {
+	err := j.transition(ctx, j.db, order, txstate.FulfilmentSuccess, "Transaksi berhasil", map[string]any{
+		"serial_number": &result.SN,
+		"ref_id":        &result.RefID,
+	})
 	if err == nil {
-		slog.Info("✅ Transaksi sukses", "order_id", order.OrderID, "sn", data.SN)
+		slog.Info("✅ Transaksi sukses", "order_id", order.OrderID, "sn", result.SN)
 	}
 	return err
 }
 
-func (j *DigiflazzTopupJob) handlePending(order *models.Transaction, message string) error {
-	status := "pending"
-	err := j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   &message,
-	}).Error
+// handleFallback memindahkan order ke kandidat berikutnya di routing table.
+// Jika tidak ada kandidat lagi (atau tagihan pascabayar), order gagal final.
+func (j *DigiflazzTopupJob) handleFallback(ctx context.Context, order *models.Transaction, attempt *models.FulfilmentAttempt, message, rc string) error {
+	if isPostpaid(order) {
+		return j.handleFailed(ctx, order, message, rc)
+	}
+
+	candidates, err := supplier.Candidates(ctx, j.db, order)
+	next := attempt.Candidate + 1
+	if err != nil || next >= len(candidates) {
+		return j.handleFailed(ctx, order, message, rc)
+	}
+
+	// Harga beli mengikuti kandidat baru supaya saldo & margin tetap benar
+	price := j.candidatePrice(ctx, order, candidates[next])
+	if err := j.requotePurchasePrice(ctx, order, price); err != nil {
+		if errors.Is(err, errInsufficientSaldo) {
+			return j.handleFailed(ctx, order, "Saldo aplikasi tidak mencukupi untuk kandidat berikutnya", "INSUFF")
+		}
+		return err
+	}
+
+	nextAttempt, err := j.startAttempt(ctx, order, attempt.Attempt+1, next, candidates[next])
+	if err != nil {
+		return err
+	}
+
+	statusMsg := fmt.Sprintf("%s gagal (%s), dialihkan ke %s", attempt.Provider, rc, nextAttempt.Provider)
+	retryAt := time.Now()
+	if err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, statusMsg, map[string]any{
+		"last_error_code": &rc,
+		"retry_at":        &retryAt,
+	}); err != nil {
+		return err
+	}
+
+	slog.Warn("↪️ Fallback ke kandidat berikutnya",
+		"order_id", order.OrderID,
+		"from", attempt.Provider+"/"+attempt.SKU,
+		"to", nextAttempt.Provider+"/"+nextAttempt.SKU,
+		"purchase_price", nextAttempt.PurchasePrice,
+		"rc", rc,
+	)
+
+	// Dari job langsung dikirim, dari callback/cek status lewat queue (atau TopupRetryScheduler)
+	if j.source == txstate.SourceTopupJob {
+		return j.sendToSupplier(ctx, order)
+	}
+	if j.queue != nil {
+		if _, err := EnqueueTopupAt(ctx, j.queue, order.ID, retryAt); err != nil {
+			slog.Error("Gagal menjadwalkan fallback", "order_id", order.OrderID, "err", err)
+		}
+	}
+	return nil
+}
+
+// candidatePrice harga beli kandidat dari katalog produk. Kandidat yang tidak
+// ada di katalog memakai harga beli order saat ini.
+func (j *DigiflazzTopupJob) candidatePrice(ctx context.Context, order *models.Transaction, c supplier.Candidate) decimal.Decimal {
+	var product models.Product
+	err := j.db.WithContext(ctx).
+		Select("id", "price").
+		Where("buyer_sku_code = ? AND provider = ?", c.SKU, c.Provider).
+		First(&product).Error
+	if err != nil || product.Price <= 0 {
+		slog.Warn("Harga kandidat tidak ditemukan, memakai harga beli order",
+			"order_id", order.OrderID, "provider", c.Provider, "sku", c.SKU, "err", err)
+		return order.PurchasePrice
+	}
+	return decimal.NewFromInt(product.Price)
+}
+
+// requotePurchasePrice mengganti purchase_price order dengan harga kandidat.
+// Jika saldo sudah dipotong, selisihnya dipotong atau dikembalikan dalam
+// transaction yang sama sehingga refundSaldo mengembalikan harga yang benar.
+func (j *DigiflazzTopupJob) requotePurchasePrice(ctx context.Context, order *models.Transaction, price decimal.Decimal) error {
+	if price.Equal(order.PurchasePrice) {
+		return nil
+	}
+
+	return j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "saldo_debited_at", "saldo_refunded_at", "purchase_price").
+			First(&locked, order.ID).Error; err != nil {
+			return err
+		}
+
+		delta, _ := price.Sub(locked.PurchasePrice).Float64()
+		if locked.SaldoDebitedAt != nil && locked.SaldoRefundedAt == nil && delta != 0 {
+			var profil models.ProfilAplikasi
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&profil).Error; err != nil {
+				return err
+			}
+			if profil.Saldo < delta {
+				return errInsufficientSaldo
+			}
+			if err := tx.Model(&profil).UpdateColumn("saldo", gorm.Expr("saldo - ?", delta)).Error; err != nil {
+				return err
+			}
+			slog.Info("Selisih harga kandidat disesuaikan ke saldo",
+				"order_id", order.OrderID,
+				"harga_lama", locked.PurchasePrice,
+				"harga_baru", price,
+				"selisih", delta,
+			)
+		}
+
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", locked.ID).Update("purchase_price", price).Error; err != nil {
+			return err
+		}
+		order.PurchasePrice = price
+		return nil
+	})
+}
+
+func (j *DigiflazzTopupJob) handlePending(ctx context.Context, order *models.Transaction, message string) error {
+	err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, message, nil)
 	if err == nil {
 		slog.Info("⏳ Menunggu callback", "order_id", order.OrderID)
 	}
 	return err
 }
 
+// handleFailed menandai order gagal dan mengembalikan saldo dalam satu database
+// transaction. Jika perpindahan ke failed ditolak (misal callback terlambat untuk
+// order yang sudah cancelled/success), saldo tidak ikut dikembalikan.
 func (j *DigiflazzTopupJob) handleFailed(ctx context.Context, order *models.Transaction, message, rc string) error {
-	// Refund saldo jika sudah didebit
-	if order.SaldoDebitedAt != nil {
-		if err := j.refundSaldo(ctx, order); err != nil {
-			slog.Error("Gagal refund saldo", "order_id", order.OrderID, "err", err)
-		} else {
-			purchasePrice, _ := order.PurchasePrice.Float64()
-			slog.Info("💸 Saldo dikembalikan", "order_id", order.OrderID, "amount", purchasePrice)
+	refunded := false
+	err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := j.transition(ctx, tx, order, txstate.FulfilmentFailed, message, map[string]any{
+			"last_error_code": &rc,
+		}); err != nil {
+			return err
 		}
+
+		// Refund saldo jika sudah didebit dan belum pernah dikembalikan
+		var err error
+		refunded, err = j.refundSaldo(ctx, tx, order)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	status := "failed"
-	err := j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   &message,
-		"last_error_code":  &rc,
-	}).Error
-	if err == nil {
-		slog.Error("❌ Transaksi gagal", "order_id", order.OrderID, "rc", rc)
+	if refunded {
+		purchasePrice, _ := order.PurchasePrice.Float64()
+		slog.Info("💸 Saldo dikembalikan", "order_id", order.OrderID, "amount", purchasePrice)
+	}
+	slog.Error("❌ Transaksi gagal", "order_id", order.OrderID, "rc", rc)
+	j.requestCustomerRefund(ctx, order, message)
+	return nil
+}
+
+// requestCustomerRefund mengembalikan dana pembeli jika pembayaran sudah settlement
+func (j *DigiflazzTopupJob) requestCustomerRefund(ctx context.Context, order *models.Transaction, message string) {
+	if _, err := refund.Request(ctx, j.db, order, "Topup gagal: "+message); err != nil && !errors.Is(err, refund.ErrNotRefundable) {
+		slog.Error("Gagal membuat refund", "order_id", order.OrderID, "err", err)
 	}
-	return err
 }
 
 func (j *DigiflazzTopupJob) handleRetryable(ctx context.Context, order *models.Transaction, message, rc string) error {
@@ -343,28 +706,22 @@ func (j *DigiflazzTopupJob) handleRetryable(ctx context.Context, order *models.T
 		return j.handleFailed(ctx, order, "Gagal setelah 5x retry", rc)
 	}
 
-	status := "pending"
 	retryAt := time.Now().Add(10 * time.Minute)
-	err := j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   &message,
-		"last_error_code":  &rc,
-		"retry_at":         &retryAt,
-	}).Error
+	err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, message, map[string]any{
+		"last_error_code": &rc,
+		"retry_at":        &retryAt,
+	})
 
 	slog.Warn("⚠️ Retry transaksi", "order_id", order.OrderID, "retry_count", order.RetryCount, "rc", rc)
 	return err
 }
 
-func (j *DigiflazzTopupJob) handleUnknown(order *models.Transaction, message, rc string) error {
-	status := "pending"
+func (j *DigiflazzTopupJob) handleUnknown(ctx context.Context, order *models.Transaction, message, rc string) error {
 	retryAt := time.Now().Add(10 * time.Minute)
-	err := j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   &message,
-		"last_error_code":  &rc,
-		"retry_at":         &retryAt,
-	}).Error
+	err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, message, map[string]any{
+		"last_error_code": &rc,
+		"retry_at":        &retryAt,
+	})
 
 	slog.Error("❓ Response code tidak dikenali", "order_id", order.OrderID, "rc", rc)
 	return err
@@ -385,29 +742,64 @@ func (j *DigiflazzTopupJob) handleException(ctx context.Context, order *models.T
 		return j.handleFailed(ctx, order, "Error: "+e.Error(), "EXCEPT")
 	}
 
-	status := "pending"
-	statusMsg := "Gangguan sistem"
 	retryAt := time.Now().Add(10 * time.Minute)
-	j.db.Model(order).Updates(map[string]any{
-		"digiflazz_status": &status,
-		"status_message":   &statusMsg,
-		"retry_at":         &retryAt,
-	})
+	if err := j.transition(ctx, j.db, order, txstate.FulfilmentPending, "Gangguan sistem", map[string]any{
+		"retry_at": &retryAt,
+	}); err != nil {
+		slog.Warn("Gagal menandai retry", "order_id", order.OrderID, "err", err)
+	}
 
 	return e // propagate ke worker supaya bisa reschedule
 }
 
 // ─── Helpers ──────────────────────────────────────────────────────────────────
 
-func (j *DigiflazzTopupJob) buildPayload(order *models.Transaction) digiflazzPayload {
-	sign := fmt.Sprintf("%x", md5.Sum([]byte(j.cfg.Username+j.cfg.ProdKey+order.OrderID)))
-	return digiflazzPayload{
-		Username:     j.cfg.Username,
-		BuyerSkuCode: order.BuyerSkuCode,
-		CustomerNo:   order.CustomerNo,
-		RefID:        order.OrderID,
-		Sign:         sign,
+// transition memindahkan status pengiriman lewat state machine txstate,
+// message disimpan sebagai status_message sekaligus catatan di log status
+func (j *DigiflazzTopupJob) transition(ctx context.Context, db *gorm.DB, order *models.Transaction, status, message string, updates map[string]any) error {
+	if updates == nil {
+		updates = map[string]any{}
 	}
+	updates["status_message"] = &message
+
+	_, err := txstate.Apply(ctx, db, order, txstate.Change{
+		Fulfilment: status,
+		Source:     j.source,
+		Note:       message,
+		Updates:    updates,
+	})
+	return err
+}
+
+func (j *DigiflazzTopupJob) buildRequest(order *models.Transaction, attempt *models.FulfilmentAttempt) supplier.Request {
+	request := supplier.Request{
+		SKU:        attempt.SKU,
+		CustomerNo: order.CustomerNo,
+		RefID:      attempt.RefID,
+		Postpaid:   isPostpaid(order),
+		Testing:    order.IsTest,
+	}
+	// Nomor uji prabayar, tagihan pascabayar memakai nomor yang sama dengan inquiry
+	if order.IsTest && !request.Postpaid {
+		request.CustomerNo = digiflazz.TestCustomerNo(order.CustomerNo)
+	}
+	return request
+}
+
+// awaitingSupplier true jika order pending sudah dikirim ke supplier dan tidak
+// ada jadwal kirim ulang yang lebih baru dari pengiriman terakhir
+func awaitingSupplier(order *models.Transaction) bool {
+	if order.DigiflazzSentAt == nil || order.DigiflazzStatus == nil {
+		return false
+	}
+	if txstate.NormalizeFulfilment(*order.DigiflazzStatus) != txstate.FulfilmentPending {
+		return false
+	}
+	return order.RetryAt == nil || !order.RetryAt.After(*order.DigiflazzSentAt)
+}
+
+func isPostpaid(order *models.Transaction) bool {
+	return order.ProductType != nil && *order.ProductType == "postpaid"
 }
 
 func (j *DigiflazzTopupJob) getAPITimeout(product *models.Product) time.Duration {
@@ -429,25 +821,6 @@ func (j *DigiflazzTopupJob) getAPITimeout(product *models.Product) time.Duration
 }
 
 
-// doHTTPPost melakukan HTTP POST request
-func (j *DigiflazzTopupJob) doHTTPPost(ctx context.Context, url string, body []byte) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
-	
-	req.Header.Set("Content-Type", "application/json")
-	
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	
-	return io.ReadAll(resp.Body)
-}
-
 // jobs/digiflazz_topup.go - Bagian Lock
 
 // SimpleLock adalah implementasi lock sederhana
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/supplier"
+	"api-arveshop-go/testdb"
+	"api-arveshop-go/txstate"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCandidatePrice(t *testing.T) {
+	db, fake := testdb.Open(t)
+	job := &DigiflazzTopupJob{db: db}
+	order := &models.Transaction{ID: 1, OrderID: "ARV-1", PurchasePrice: decimal.NewFromInt(5150)}
+	candidate := supplier.Candidate{Provider: supplier.DefaultProvider, SKU: "TSEL5B"}
+
+	fake.SetRows("products", testdb.Row{"id": int64(2), "price": int64(5300)})
+	if got := job.candidatePrice(context.Background(), order, candidate); !got.Equal(decimal.NewFromInt(5300)) {
+		t.Errorf("candidatePrice = %s, want 5300", got)
+	}
+
+	// SKU di luar katalog tetap memakai harga beli order
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	fake.SetRows("products")
+	if got := job.candidatePrice(context.Background(), order, candidate); !got.Equal(order.PurchasePrice) {
+		t.Errorf("candidatePrice tanpa produk = %s, want %s", got, order.PurchasePrice)
+	}
+}
+
+func TestRequotePurchasePrice(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	debitedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		debited     bool
+		saldo       float64
+		price       int64
+		wantErr     error
+		wantSaldo   bool
+		wantDelta   float64
+		wantRequote bool
+	}{
+		{name: "lebih mahal, selisih dipotong", debited: true, saldo: 100000, price: 5300, wantSaldo: true, wantDelta: 150, wantRequote: true},
+		{name: "lebih murah, selisih dikembalikan", debited: true, saldo: 100000, price: 5000, wantSaldo: true, wantDelta: -150, wantRequote: true},
+		{name: "saldo belum dipotong", debited: false, saldo: 0, price: 5300, wantRequote: true},
+		{name: "saldo tidak cukup", debited: true, saldo: 100, price: 5300, wantErr: errInsufficientSaldo},
+		{name: "harga sama", debited: true, saldo: 100000, price: 5150},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := testdb.Open(t)
+			job := &DigiflazzTopupJob{db: db}
+
+			row := testdb.Row{"id": int64(1), "purchase_price": "5150"}
+			if tt.debited {
+				row["saldo_debited_at"] = debitedAt
+			}
+			fake.SetRows("transactions", row)
+			fake.SetRows("profil_aplikasis", testdb.Row{"id": int64(1), "saldo": tt.saldo})
+
+			order := &models.Transaction{ID: 1, OrderID: "ARV-1", PurchasePrice: decimal.NewFromInt(5150)}
+			err := job.requotePurchasePrice(context.Background(), order, decimal.NewFromInt(tt.price))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("requotePurchasePrice() error = %v, want %v", err, tt.wantErr)
+			}
+
+			saldo := fake.Execs("UPDATE `profil_aplikasis`")
+			if got := len(saldo) == 1; got != tt.wantSaldo {
+				t.Fatalf("update saldo = %d, want %v", len(saldo), tt.wantSaldo)
+			}
+			if tt.wantSaldo && !saldo[0].Has(tt.wantDelta) {
+				t.Errorf("selisih saldo = %v, want %v", saldo[0].Args, tt.wantDelta)
+			}
+
+			requote := fake.Execs("UPDATE `transactions`")
+			if got := len(requote) == 1; got != tt.wantRequote {
+				t.Fatalf("update purchase_price = %d, want %v", len(requote), tt.wantRequote)
+			}
+			if tt.wantRequote {
+				if !requote[0].Has(decimal.NewFromInt(tt.price).String()) {
+					t.Errorf("purchase_price = %v, want %d", requote[0].Args, tt.price)
+				}
+				if !order.PurchasePrice.Equal(decimal.NewFromInt(tt.price)) {
+					t.Errorf("order.PurchasePrice = %s, want %d", order.PurchasePrice, tt.price)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessTopupDebitFailedRefundsCustomer(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name   string
+		profil []testdb.Row
+		rc     string
+	}{
+		{name: "profil tidak ada", rc: "NOPROF"},
+		{name: "saldo tidak cukup", profil: []testdb.Row{{"id": int64(1), "saldo": float64(100)}}, rc: "INSUFF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := testdb.Open(t)
+			previous := config.DB
+			config.DB = db
+			t.Cleanup(func() { config.DB = previous })
+
+			job := &DigiflazzTopupJob{db: db, source: txstate.SourceTopupJob}
+			fake.SetRows("transactions", testdb.Row{
+				"id": int64(1), "order_id": "ARV-1", "payment_status": payment.StatusSettlement,
+				"gross_amount": "6000", "purchase_price": "5150",
+			})
+			fake.SetRows("profil_aplikasis", tt.profil...)
+
+			order := &models.Transaction{ID: 1, OrderID: "ARV-1", PaymentStatus: payment.StatusSettlement, PurchasePrice: decimal.NewFromInt(5150)}
+			if err := job.processTopup(context.Background(), order); err != nil {
+				t.Fatalf("processTopup() error = %v", err)
+			}
+
+			if saldo := fake.Execs("UPDATE `profil_aplikasis`"); len(saldo) != 0 {
+				t.Errorf("update saldo = %d, want 0", len(saldo))
+			}
+
+			failed := false
+			for _, update := range fake.Execs("UPDATE `transactions`") {
+				if update.Has(txstate.FulfilmentFailed) && update.Has(tt.rc) {
+					failed = true
+				}
+			}
+			if !failed {
+				t.Errorf("order tidak ditandai failed dengan rc %s", tt.rc)
+			}
+
+			if refunds := fake.Execs("INSERT INTO `refunds`"); len(refunds) != 1 {
+				t.Errorf("insert refunds = %d, want 1", len(refunds))
+			}
+		})
+	}
+}
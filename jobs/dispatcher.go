@@ -2,7 +2,10 @@
 package jobs
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -20,4 +23,28 @@ func NewDigiflazzTopupTask(orderID uint) (*asynq.Task, error) {
         asynq.MaxRetry(5),
         asynq.Timeout(5*time.Minute),        
     ), nil
-}
\ No newline at end of file
+}
+
+// EnqueueTopupAt mengantrikan topup untuk dijalankan pada waktu at.
+// Task ID topup:<id>:<unix> mencegah jadwal yang sama diantrikan dua kali,
+// false berarti task untuk jadwal tersebut sudah ada di queue.
+// Retry asynq dimatikan karena jadwal ulang dikendalikan retry_at.
+func EnqueueTopupAt(ctx context.Context, client *asynq.Client, orderID uint, at time.Time) (bool, error) {
+	task, err := NewDigiflazzTopupTask(orderID)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = client.EnqueueContext(ctx, task,
+		asynq.ProcessAt(at),
+		asynq.TaskID(fmt.Sprintf("topup:%d:%d", orderID, at.Unix())),
+		asynq.MaxRetry(0),
+	)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,102 @@
+// jobs/payment_expire.go — expire transaksi yang melewati batas waktu bayar
+package jobs
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/txstate"
+	"api-arveshop-go/websocket"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskPaymentExpire = "payment:expire"
+
+// PaymentExpirer menandai transaksi pending yang lewat expired_at sebagai expired,
+// membatalkannya di gateway dan mengirim update via WebSocket
+type PaymentExpirer struct {
+	db        *gorm.DB
+	apply     ApplyPaymentFunc
+	batchSize int
+}
+
+func NewPaymentExpirer(db *gorm.DB, apply ApplyPaymentFunc) *PaymentExpirer {
+	return &PaymentExpirer{db: db, apply: apply, batchSize: 100}
+}
+
+func NewPaymentExpireTask() *asynq.Task {
+	return asynq.NewTask(TaskPaymentExpire, nil, asynq.MaxRetry(0), asynq.Timeout(5*time.Minute))
+}
+
+func (e *PaymentExpirer) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	var transactions []models.Transaction
+	err := e.db.WithContext(ctx).
+		Where("payment_status = ?", payment.StatusPending).
+		Where("expired_at IS NOT NULL AND expired_at <= ?", time.Now()).
+		Order("expired_at ASC").
+		Limit(e.batchSize).
+		Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range transactions {
+		e.expire(ctx, &transactions[i])
+	}
+
+	if len(transactions) > 0 {
+		slog.Info("Expire transaksi selesai", "jumlah", len(transactions))
+	}
+	return nil
+}
+
+func (e *PaymentExpirer) expire(ctx context.Context, transaction *models.Transaction) {
+	gateway, err := payment.Get(transaction.PaymentGateway)
+	if err != nil {
+		slog.Warn("Gateway tidak terdaftar", "order_id", transaction.OrderID, "gateway", transaction.PaymentGateway)
+		return
+	}
+
+	ref := payment.Reference{OrderID: transaction.OrderID}
+	if transaction.TransactionID != nil {
+		ref.TransactionID = *transaction.TransactionID
+	}
+
+	// Pastikan customer belum membayar tepat sebelum batas waktu
+	if notification, err := gateway.Status(ctx, ref); err == nil && notification.IsPaid() {
+		if _, err := e.apply(transaction, *notification); err != nil {
+			slog.Error("Gagal menerapkan status gateway", "order_id", transaction.OrderID, "err", err)
+		}
+		return
+	}
+
+	if _, err := gateway.Cancel(ctx, ref); err != nil && !errors.Is(err, payment.ErrNotSupported) {
+		// Tetap expire di sisi kita, gateway akan meng-expire dengan sendirinya
+		slog.Warn("Gagal cancel di gateway", "order_id", transaction.OrderID, "err", err)
+	}
+
+	statusMsg := "Batas waktu pembayaran habis"
+	_, err = txstate.Apply(ctx, e.db, transaction, txstate.Change{
+		Payment:    payment.StatusExpired,
+		Fulfilment: txstate.FulfilmentCancelled,
+		Source:     txstate.SourcePaymentExpire,
+		Note:       statusMsg,
+		Updates:    map[string]any{"status_message": &statusMsg},
+	})
+	if errors.Is(err, txstate.ErrIllegalTransition) {
+		// Status sudah berubah (misal dibayar) sejak dipilih
+		return
+	}
+	if err != nil {
+		slog.Error("Gagal expire transaksi", "order_id", transaction.OrderID, "err", err)
+		return
+	}
+
+	slog.Info("⌛ Transaksi expired", "order_id", transaction.OrderID)
+	websocket.BroadcastOrderStatus(transaction.OrderID)
+}
@@ -0,0 +1,114 @@
+// jobs/payment_reconcile.go — cek status pembayaran yang tertinggal di pending
+package jobs
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskPaymentReconcile = "payment:reconcile"
+
+// ApplyPaymentFunc menerapkan status dari gateway ke transaksi (sama seperti webhook)
+type ApplyPaymentFunc func(transaction *models.Transaction, notification payment.Notification) (string, error)
+
+type PaymentReconcileConfig struct {
+	// Transaksi pending lebih lama dari ini akan dicek ke gateway
+	OlderThan time.Duration
+	// Transaksi lebih tua dari ini tidak dicek lagi
+	MaxAge    time.Duration
+	BatchSize int
+}
+
+// PaymentReconciler mencari transaksi pending yang notifikasinya tidak pernah
+// sampai, lalu menanyakan status langsung ke gateway
+type PaymentReconciler struct {
+	db    *gorm.DB
+	apply ApplyPaymentFunc
+	cfg   PaymentReconcileConfig
+}
+
+func NewPaymentReconciler(db *gorm.DB, apply ApplyPaymentFunc, cfg PaymentReconcileConfig) *PaymentReconciler {
+	if cfg.OlderThan <= 0 {
+		cfg.OlderThan = 15 * time.Minute
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 48 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &PaymentReconciler{db: db, apply: apply, cfg: cfg}
+}
+
+func NewPaymentReconcileTask() *asynq.Task {
+	return asynq.NewTask(TaskPaymentReconcile, nil, asynq.MaxRetry(0), asynq.Timeout(5*time.Minute))
+}
+
+func (r *PaymentReconciler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).
+		Where("payment_status = ?", payment.StatusPending).
+		Where("created_at <= ? AND created_at >= ?", now.Add(-r.cfg.OlderThan), now.Add(-r.cfg.MaxAge)).
+		Order("created_at ASC").
+		Limit(r.cfg.BatchSize).
+		Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	slog.Info("Reconcile pembayaran pending", "jumlah", len(transactions))
+
+	updated := 0
+	for i := range transactions {
+		if r.reconcile(ctx, &transactions[i]) {
+			updated++
+		}
+	}
+
+	slog.Info("Reconcile selesai", "dicek", len(transactions), "diupdate", updated)
+	return nil
+}
+
+func (r *PaymentReconciler) reconcile(ctx context.Context, transaction *models.Transaction) bool {
+	gateway, err := payment.Get(transaction.PaymentGateway)
+	if err != nil {
+		slog.Warn("Gateway tidak terdaftar", "order_id", transaction.OrderID, "gateway", transaction.PaymentGateway)
+		return false
+	}
+
+	ref := payment.Reference{OrderID: transaction.OrderID}
+	if transaction.TransactionID != nil {
+		ref.TransactionID = *transaction.TransactionID
+	}
+
+	notification, err := gateway.Status(ctx, ref)
+	if err != nil {
+		slog.Warn("Gagal cek status ke gateway", "order_id", transaction.OrderID, "err", err)
+		return false
+	}
+
+	if notification.Status == payment.StatusPending || notification.Status == payment.StatusUnknown {
+		return false
+	}
+
+	newStatus, err := r.apply(transaction, *notification)
+	if err != nil {
+		slog.Error("Gagal menerapkan status gateway", "order_id", transaction.OrderID, "err", err)
+		return false
+	}
+
+	slog.Info("🔄 Status pembayaran direkonsiliasi", "order_id", transaction.OrderID, "status", newStatus)
+	return true
+}
@@ -15,10 +15,11 @@ type DigiflazzProcessor struct {
     db  *gorm.DB
     rdb *redis.Client
     cfg DigiflazzConfig
+    queue *asynq.Client
 }
 
-func NewDigiflazzProcessor(db *gorm.DB, rdb *redis.Client, cfg DigiflazzConfig) *DigiflazzProcessor {
-    return &DigiflazzProcessor{db: db, rdb: rdb, cfg: cfg}
+func NewDigiflazzProcessor(db *gorm.DB, rdb *redis.Client, cfg DigiflazzConfig, queue *asynq.Client) *DigiflazzProcessor {
+    return &DigiflazzProcessor{db: db, rdb: rdb, cfg: cfg, queue: queue}
 }
 
 func (p *DigiflazzProcessor) ProcessTask(ctx context.Context, t *asynq.Task) error {
@@ -27,6 +28,6 @@ func (p *DigiflazzProcessor) ProcessTask(ctx context.Context, t *asynq.Task) err
         return fmt.Errorf("invalid payload: %w", err)
     }
 
-    job := NewDigiflazzTopupJob(payload.OrderID, p.db, p.rdb, p.cfg)
+    job := NewDigiflazzTopupJob(payload.OrderID, p.db, p.rdb, p.cfg).WithQueue(p.queue)
     return job.Handle(ctx)
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+// jobs/topup_retry.go — jadwalkan ulang topup yang retry_at-nya sudah tiba
+package jobs
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/txstate"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskTopupRetry = "digiflazz:topup-retry"
+
+type TopupRetryConfig struct {
+	// Transaksi dengan retry_at sampai now + Lookahead ikut dijadwalkan,
+	// supaya ProcessAt tetap tepat walaupun scan berjalan periodik
+	Lookahead time.Duration
+	BatchSize int
+}
+
+// TopupRetryScheduler mencari transaksi pending yang retry_at-nya sudah lewat
+// (atau segera tiba) lalu mengantrikan topup tepat pada retry_at
+type TopupRetryScheduler struct {
+	db     *gorm.DB
+	client *asynq.Client
+	cfg    TopupRetryConfig
+}
+
+func NewTopupRetryScheduler(db *gorm.DB, client *asynq.Client, cfg TopupRetryConfig) *TopupRetryScheduler {
+	if cfg.Lookahead < 0 {
+		cfg.Lookahead = 0
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &TopupRetryScheduler{db: db, client: client, cfg: cfg}
+}
+
+// NewTopupRetryTask dibuat unik supaya scan tidak berjalan bertumpuk
+func NewTopupRetryTask() *asynq.Task {
+	return asynq.NewTask(TaskTopupRetry, nil,
+		asynq.MaxRetry(0),
+		asynq.Timeout(time.Minute),
+		asynq.Unique(time.Minute),
+	)
+}
+
+func (s *TopupRetryScheduler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	var transactions []models.Transaction
+	err := dueRetryQuery(s.db.WithContext(ctx), time.Now().Add(s.cfg.Lookahead)).
+		Select("id", "order_id", "retry_at").
+		Order("retry_at ASC").
+		Limit(s.cfg.BatchSize).
+		Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	enqueued := 0
+	for _, transaction := range transactions {
+		ok, err := EnqueueTopupAt(ctx, s.client, transaction.ID, *transaction.RetryAt)
+		if err != nil {
+			slog.Error("Gagal menjadwalkan retry topup", "order_id", transaction.OrderID, "err", err)
+			continue
+		}
+		if ok {
+			enqueued++
+		}
+	}
+
+	if enqueued > 0 {
+		slog.Info("🔁 Retry topup dijadwalkan", "jumlah", enqueued, "dicek", len(transactions))
+	}
+	return nil
+}
+
+// dueRetryQuery memilih order pending yang retry_at-nya tiba sebelum until.
+// retry_at yang tidak lebih baru dari digiflazz_sent_at sudah dipakai untuk
+// pengiriman sebelumnya, order tersebut menunggu callback, bukan dikirim ulang.
+func dueRetryQuery(db *gorm.DB, until time.Time) *gorm.DB {
+	return db.Model(&models.Transaction{}).
+		Where("payment_status = ? AND digiflazz_status = ?", payment.StatusSettlement, txstate.FulfilmentPending).
+		Where("retry_at IS NOT NULL AND retry_at <= ?", until).
+		Where("digiflazz_sent_at IS NULL OR retry_at > digiflazz_sent_at")
+}
@@ -0,0 +1,85 @@
+package jobs
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/testdb"
+	"api-arveshop-go/txstate"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDueRetryQuerySkipsUsedRetryAt(t *testing.T) {
+	db := testdb.DryRun(t)
+
+	var transactions []models.Transaction
+	stmt := dueRetryQuery(db, time.Now()).Find(&transactions).Statement
+	sql := stmt.SQL.String()
+
+	for _, want := range []string{
+		"retry_at IS NOT NULL AND retry_at <= ?",
+		"digiflazz_sent_at IS NULL OR retry_at > digiflazz_sent_at",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("query tidak memuat %q:\n%s", want, sql)
+		}
+	}
+}
+
+func TestAwaitingSupplier(t *testing.T) {
+	sentAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	before := sentAt.Add(-time.Minute)
+	after := sentAt.Add(10 * time.Minute)
+
+	status := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		order models.Transaction
+		want  bool
+	}{
+		{
+			name:  "belum pernah dikirim",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentPending), RetryAt: &before},
+			want:  false,
+		},
+		{
+			name:  "pending tanpa retry_at setelah dikirim",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentPending), DigiflazzSentAt: &sentAt},
+			want:  true,
+		},
+		{
+			name:  "retry_at lama sebelum pengiriman terakhir",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentPending), DigiflazzSentAt: &sentAt, RetryAt: &before},
+			want:  true,
+		},
+		{
+			name:  "retry_at sama dengan pengiriman terakhir",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentPending), DigiflazzSentAt: &sentAt, RetryAt: &sentAt},
+			want:  true,
+		},
+		{
+			name:  "retry dijadwalkan setelah pengiriman",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentPending), DigiflazzSentAt: &sentAt, RetryAt: &after},
+			want:  false,
+		},
+		{
+			name:  "status lama Pending",
+			order: models.Transaction{DigiflazzStatus: status("Pending"), DigiflazzSentAt: &sentAt},
+			want:  true,
+		},
+		{
+			name:  "masih processing",
+			order: models.Transaction{DigiflazzStatus: status(txstate.FulfilmentProcessing), DigiflazzSentAt: &sentAt},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := awaitingSupplier(&tt.order); got != tt.want {
+				t.Errorf("awaitingSupplier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// jobs/topup_status_check.go — cek ulang order pending yang callback-nya tidak datang
+package jobs
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/txstate"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const TaskTopupStatusCheck = "digiflazz:status-check"
+
+type TopupStatusCheckConfig struct {
+	// Order pending yang dikirim lebih lama dari ini akan dicek ke Digiflazz
+	OlderThan time.Duration
+	// Order yang masih pending setelah ini tidak dicek lagi & dieskalasi ke admin
+	MaxAge    time.Duration
+	BatchSize int
+}
+
+// TopupStatusChecker mencari order yang tertahan di pending (rc 201/03 tanpa
+// callback) lalu menanyakan statusnya langsung ke Digiflazz
+type TopupStatusChecker struct {
+	db        *gorm.DB
+	rdb       *redis.Client
+	digiflazz DigiflazzConfig
+	cfg       TopupStatusCheckConfig
+}
+
+func NewTopupStatusChecker(db *gorm.DB, rdb *redis.Client, digiflazz DigiflazzConfig, cfg TopupStatusCheckConfig) *TopupStatusChecker {
+	if cfg.OlderThan <= 0 {
+		cfg.OlderThan = 5 * time.Minute
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = 24 * time.Hour
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	return &TopupStatusChecker{db: db, rdb: rdb, digiflazz: digiflazz, cfg: cfg}
+}
+
+// NewTopupStatusCheckTask dibuat unik supaya pengecekan tidak berjalan bertumpuk
+func NewTopupStatusCheckTask() *asynq.Task {
+	return asynq.NewTask(TaskTopupStatusCheck, nil,
+		asynq.MaxRetry(0),
+		asynq.Timeout(5*time.Minute),
+		asynq.Unique(5*time.Minute),
+	)
+}
+
+func (c *TopupStatusChecker) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	now := time.Now()
+
+	if err := c.escalate(ctx, now); err != nil {
+		slog.Error("Gagal eskalasi order pending", "err", err)
+	}
+
+	// Order yang menunggu retry_at diurus TopupRetryScheduler
+	var transactions []models.Transaction
+	err := c.pendingQuery(ctx).
+		Select("id", "order_id").
+		Where("digiflazz_sent_at <= ? AND digiflazz_sent_at >= ?", now.Add(-c.cfg.OlderThan), now.Add(-c.cfg.MaxAge)).
+		Where("retry_at IS NULL OR retry_at < digiflazz_sent_at").
+		Order("digiflazz_sent_at ASC").
+		Limit(c.cfg.BatchSize).
+		Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	for _, transaction := range transactions {
+		job := NewDigiflazzTopupJob(transaction.ID, c.db, c.rdb, c.digiflazz)
+		if err := job.CheckStatus(ctx); err != nil {
+			slog.Warn("Gagal cek status Digiflazz", "order_id", transaction.OrderID, "err", err)
+		}
+	}
+
+	slog.Info("🔎 Cek status order pending selesai", "dicek", len(transactions))
+	return nil
+}
+
+// escalate menandai order yang tetap pending melewati MaxAge supaya ditangani admin
+func (c *TopupStatusChecker) escalate(ctx context.Context, now time.Time) error {
+	var transactions []models.Transaction
+	err := c.pendingQuery(ctx).
+		Select("id", "order_id", "digiflazz_sent_at").
+		Where("digiflazz_sent_at < ?", now.Add(-c.cfg.MaxAge)).
+		Where("escalated_at IS NULL").
+		Limit(c.cfg.BatchSize).
+		Find(&transactions).Error
+	if err != nil || len(transactions) == 0 {
+		return err
+	}
+
+	ids := make([]uint, 0, len(transactions))
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+		slog.Warn("🚨 Order pending dieskalasi ke admin", "order_id", transaction.OrderID, "sent_at", transaction.DigiflazzSentAt)
+	}
+
+	return c.db.WithContext(ctx).
+		Model(&models.Transaction{}).
+		Where("id IN ?", ids).
+		Update("escalated_at", &now).Error
+}
+
+func (c *TopupStatusChecker) pendingQuery(ctx context.Context) *gorm.DB {
+	return c.db.WithContext(ctx).
+		Model(&models.Transaction{}).
+		Where("payment_status = ? AND digiflazz_status = ?", payment.StatusSettlement, txstate.FulfilmentPending).
+		Where("digiflazz_sent_at IS NOT NULL")
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"api-arveshop-go/catalog"
 	"api-arveshop-go/config"
+	"api-arveshop-go/controllers"
+	"api-arveshop-go/digiflazz"
 	"api-arveshop-go/jobs"
 	"api-arveshop-go/models"
+	"api-arveshop-go/orderid"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/refund"
 	"api-arveshop-go/routes"
+	"api-arveshop-go/supplier"
+	"api-arveshop-go/txstate"
 	"api-arveshop-go/utils"
 	"log"
 	"os"
@@ -24,15 +32,24 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key", "X-Storefront"},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	// Database
 	config.ConnectDB()
-	config.DB.AutoMigrate(
+	// Unique index buyer_sku_code gagal dibuat selama masih ada SKU ganda
+	if removed, err := catalog.DedupePasca(config.DB); err != nil {
+		log.Fatal("❌ Gagal menghapus produk pasca ganda: ", err)
+	} else if removed > 0 {
+		log.Printf("🧹 %d produk pasca ganda dihapus sebelum migrasi", removed)
+	}
+	if err := refund.DropTransactionUnique(config.DB); err != nil {
+		log.Fatal("❌ Gagal menghapus unique index refunds.transaction_id: ", err)
+	}
+	if err := config.DB.AutoMigrate(
 		&models.User{},
 		&models.Whatsapp{},
 		&models.Transaction{},
@@ -41,12 +58,50 @@ func main() {
 		&models.ProductPasca{},
 		&models.PaymentMethod{},
 		&models.Category{},
-		&models.ProfilAplikasi{},		
-	)
+		&models.ProfilAplikasi{},
+		&models.Refund{},
+		&models.PricingRule{},
+		&models.SyncReport{},
+		&models.BalanceSnapshot{},
+		&models.BalanceCorrection{},
+		&models.TransactionStatusLog{},
+		&models.ProductRoute{},
+		&models.FulfilmentAttempt{},
+	); err != nil {
+		log.Fatal("❌ AutoMigrate gagal: ", err)
+	}
+	if err := txstate.MigrateLegacy(config.DB); err != nil {
+		log.Printf("Gagal normalisasi status transaksi lama: %v", err)
+	}
 
 	// Redis untuk Asynq
 	config.InitRedis()
 
+	// Generator order_id
+	orderid.Init(config.RDB)
+
+	// Payment gateway
+	payment.Register(payment.NewMidtransFromEnv())
+	if os.Getenv("TRIPAY_API_KEY") != "" {
+		tripay := payment.NewTripayFromEnv()
+		if err := tripay.Validate(); err != nil {
+			log.Printf("❌ Tripay tidak didaftarkan: %v", err)
+		} else {
+			payment.Register(tripay)
+		}
+	}
+
+	if digiflazz.TestingEnabled() {
+		// Pembeli membayar sungguhan tapi produk tidak dikirim, jangan sampai terjadi
+		if payment.ProductionFromEnv() {
+			log.Fatal("❌ DIGIFLAZZ_TESTING tidak boleh aktif bersama payment gateway production")
+		}
+		log.Println("⚠️ Digiflazz testing mode aktif: transaksi dikirim dengan testing: true, saldo tidak dipotong")
+	}
+
+	// Supplier produk, Digiflazz selalu tersedia
+	supplier.Register(supplier.NewDigiflazz(digiflazz.NewFromEnv()))
+
 	// Cloudinary
 	if err := utils.InitCloudinary(); err != nil {
 		log.Fatal("Failed to initialize Cloudinary: ", err)
@@ -54,6 +109,7 @@ func main() {
 
 	// 🟢 JALANKAN WORKER DI GOROUTINE
 	go startWorker()
+	go startScheduler()
 
 	// Routes
 	routes.SetupRoutes(r)
@@ -63,18 +119,33 @@ func main() {
 	r.Run(":8080")
 }
 
-func startWorker() {
+func asynqRedisOpt() asynq.RedisClientOpt {
 	// 🔴 PERBAIKAN 1: Set default Redis address
 	redisAddr := os.Getenv("REDIS_ADDR")
 	if redisAddr == "" {
 		redisAddr = "127.0.0.1:6379" // default asynq
 	}
 
-	redisOpt := asynq.RedisClientOpt{
+	return asynq.RedisClientOpt{
 		Addr:     redisAddr,
 		Password: os.Getenv("REDIS_PASSWORD"),
 		DB:       0, // 🔴 PERBAIKAN 2: Tambahkan DB jika perlu
 	}
+}
+
+// envDuration membaca durasi dari env (format time.ParseDuration), atau default
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		log.Printf("⚠️ %s tidak valid: %q, pakai default %s", key, v, def)
+	}
+	return def
+}
+
+func startWorker() {
+	redisOpt := asynqRedisOpt()
 
 	// 🔴 PERBAIKAN 3: Cek koneksi Redis dulu
 	client := asynq.NewClient(redisOpt)
@@ -109,20 +180,45 @@ func startWorker() {
 },
 	})
 
+	digiflazzCfg := jobs.DigiflazzConfig{
+		Username: os.Getenv("DIGIFLAZZ_USERNAME"),
+		ProdKey:  digiflazz.APIKeyFromEnv(),
+		BaseURL:  os.Getenv("DIGIFLAZZ_BASE_URL"),
+	}
+
 	// Processor
-	processor := jobs.NewDigiflazzProcessor(
+	processor := jobs.NewDigiflazzProcessor(config.DB, config.RDB, digiflazzCfg, client)
+
+	reconciler := jobs.NewPaymentReconciler(
 		config.DB,
-		config.RDB,
-		jobs.DigiflazzConfig{
-			Username: os.Getenv("DIGIFLAZZ_USERNAME"),
-			ProdKey:  os.Getenv("DIGIFLAZZ_PROD_KEY"),
-			BaseURL:  "https://api.digiflazz.com",
+		controllers.ApplyPaymentNotification,
+		jobs.PaymentReconcileConfig{
+			OlderThan: envDuration("PAYMENT_RECONCILE_AFTER", 15*time.Minute),
+			MaxAge:    envDuration("PAYMENT_RECONCILE_MAX_AGE", 48*time.Hour),
 		},
 	)
 
+	expirer := jobs.NewPaymentExpirer(config.DB, controllers.ApplyPaymentNotification)
+
+	catalogSyncer := jobs.NewCatalogSyncer(config.DB, digiflazz.NewFromEnv())
+	balanceChecker := jobs.NewBalanceChecker(config.DB, digiflazz.NewFromEnv())
+	statusChecker := jobs.NewTopupStatusChecker(config.DB, config.RDB, digiflazzCfg, jobs.TopupStatusCheckConfig{
+		OlderThan: envDuration("DIGIFLAZZ_STATUS_CHECK_AFTER", 5*time.Minute),
+		MaxAge:    envDuration("DIGIFLAZZ_STATUS_CHECK_MAX_AGE", 24*time.Hour),
+	})
+	topupRetry := jobs.NewTopupRetryScheduler(config.DB, client, jobs.TopupRetryConfig{
+		Lookahead: envDuration("TOPUP_RETRY_INTERVAL", time.Minute),
+	})
+
 	// Router
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(jobs.TaskDigiflazzTopup, processor.ProcessTask)
+	mux.HandleFunc(jobs.TaskPaymentReconcile, reconciler.ProcessTask)
+	mux.HandleFunc(jobs.TaskPaymentExpire, expirer.ProcessTask)
+	mux.HandleFunc(jobs.TaskCatalogSync, catalogSyncer.ProcessTask)
+	mux.HandleFunc(jobs.TaskBalanceCheck, balanceChecker.ProcessTask)
+	mux.HandleFunc(jobs.TaskTopupRetry, topupRetry.ProcessTask)
+	mux.HandleFunc(jobs.TaskTopupStatusCheck, statusChecker.ProcessTask)
 
 	// 🔴 PERBAIKAN 5: Tambahkan log
 	log.Println("👷 Worker started, waiting for jobs...")
@@ -131,4 +227,47 @@ func startWorker() {
 	if err := srv.Run(mux); err != nil {
 		log.Printf("❌ Worker error: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+// startScheduler mendaftarkan task periodik ke asynq
+func startScheduler() {
+	scheduler := asynq.NewScheduler(asynqRedisOpt(), &asynq.SchedulerOpts{
+		Location: time.Local,
+	})
+
+	reconcileEvery := envDuration("PAYMENT_RECONCILE_INTERVAL", 5*time.Minute)
+	if _, err := scheduler.Register("@every "+reconcileEvery.String(), jobs.NewPaymentReconcileTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan payment reconcile: %v", err)
+	}
+
+	expireEvery := envDuration("PAYMENT_EXPIRE_INTERVAL", time.Minute)
+	if _, err := scheduler.Register("@every "+expireEvery.String(), jobs.NewPaymentExpireTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan payment expire: %v", err)
+	}
+
+	catalogEvery := envDuration("CATALOG_SYNC_INTERVAL", 30*time.Minute)
+	if _, err := scheduler.Register("@every "+catalogEvery.String(), jobs.NewCatalogSyncTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan catalog sync: %v", err)
+	}
+
+	balanceEvery := envDuration("BALANCE_CHECK_INTERVAL", 15*time.Minute)
+	if _, err := scheduler.Register("@every "+balanceEvery.String(), jobs.NewBalanceCheckTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan balance check: %v", err)
+	}
+
+	retryEvery := envDuration("TOPUP_RETRY_INTERVAL", time.Minute)
+	if _, err := scheduler.Register("@every "+retryEvery.String(), jobs.NewTopupRetryTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan topup retry: %v", err)
+	}
+
+	statusCheckEvery := envDuration("DIGIFLAZZ_STATUS_CHECK_INTERVAL", 5*time.Minute)
+	if _, err := scheduler.Register("@every "+statusCheckEvery.String(), jobs.NewTopupStatusCheckTask()); err != nil {
+		log.Printf("❌ Gagal mendaftarkan cek status topup: %v", err)
+	}
+
+	log.Println("⏰ Scheduler started")
+
+	if err := scheduler.Run(); err != nil {
+		log.Printf("❌ Scheduler error: %v", err)
+	}
+}
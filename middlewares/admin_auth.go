@@ -0,0 +1,38 @@
+// middlewares/admin_auth.go
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminAuth membatasi route admin dengan API key (ADMIN_API_KEY).
+// Key dikirim lewat header X-Admin-Key atau Authorization: Bearer <key>.
+// Tanpa key yang dikonfigurasi semua request ditolak, bukan dibuka.
+func AdminAuth(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin API belum dikonfigurasi"})
+			return
+		}
+
+		key := c.GetHeader(AdminKeyHeader)
+		if key == "" {
+			if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+				key = strings.TrimSpace(bearer)
+			}
+		}
+
+		if key == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		headers map[string]string
+		want    int
+	}{
+		{"tanpa key dikonfigurasi", "", map[string]string{AdminKeyHeader: ""}, http.StatusServiceUnavailable},
+		{"tanpa header", "secret", nil, http.StatusUnauthorized},
+		{"key salah", "secret", map[string]string{AdminKeyHeader: "wrong"}, http.StatusUnauthorized},
+		{"header X-Admin-Key", "secret", map[string]string{AdminKeyHeader: "secret"}, http.StatusOK},
+		{"bearer token", "secret", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"skema selain bearer", "secret", map[string]string{"Authorization": "Basic secret"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/api/admin/products/sync", AdminAuth(tt.apiKey), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/products/sync", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// middlewares/idempotency.go
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+
+	idempotencyTTL       = 24 * time.Hour
+	idempotencyMaxKeyLen = 255
+
+	// Batas waktu menyimpan response / melepas key setelah handler selesai
+	idempotencyStoreTimeout = 5 * time.Second
+)
+
+// idempotencyLockTTL adalah umur lock request yang sedang diproses. Lock
+// diperpanjang selama handler berjalan (lihat keepIdempotencyLock), jadi nilai
+// ini hanya menentukan seberapa cepat key dilepas jika proses mati di tengah request.
+var idempotencyLockTTL = 30 * time.Second
+
+// idempotencyRecord disimpan di Redis per Idempotency-Key
+type idempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// responseRecorder menyalin body response supaya bisa disimpan ke Redis
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency menyimpan response pertama untuk setiap Idempotency-Key.
+// Request ulang dengan key & body yang sama mendapat response yang sama,
+// request ulang dengan body berbeda ditolak dengan 409.
+// Request tanpa header diteruskan apa adanya.
+func Idempotency(rdb *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotencyMaxKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key terlalu panjang"})
+			return
+		}
+
+		bodyBytes, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		ctx := c.Request.Context()
+		redisKey := "idempotency:" + c.FullPath() + ":" + key
+		requestHash := hashRequest(c.Request.Method, c.FullPath(), bodyBytes)
+
+		// Klaim key, hanya satu request yang boleh memproses
+		pending, _ := json.Marshal(idempotencyRecord{RequestHash: requestHash})
+		claimed, err := rdb.SetNX(ctx, redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Printf("⚠️ Idempotency tidak aktif, Redis error: %v", err)
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			replayIdempotentResponse(c, rdb, redisKey, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// Jangan pakai context request: jika client sudah putus, Set/Del ikut
+		// batal dan retry dengan key yang sama akan memproses ulang transaksi
+		stopRefresh := keepIdempotencyLock(context.WithoutCancel(ctx), rdb, redisKey)
+		c.Next()
+		stopRefresh()
+
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+		storeIdempotentResponse(storeCtx, rdb, redisKey, requestHash, recorder)
+	}
+}
+
+// keepIdempotencyLock memperpanjang lock selama handler masih berjalan, sehingga
+// request ulang tetap mendapat 409 walaupun handler (misal charge ke gateway)
+// lebih lama dari idempotencyLockTTL. Fungsi yang dikembalikan menghentikan
+// perpanjangan dan menunggu sampai berhenti, supaya tidak menimpa TTL response.
+func keepIdempotencyLock(ctx context.Context, rdb *redis.Client, redisKey string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := rdb.PExpire(ctx, redisKey, idempotencyLockTTL).Err(); err != nil {
+					log.Printf("Failed to refresh idempotency lock %s: %v", redisKey, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, rdb *redis.Client, redisKey, requestHash string) {
+	raw, err := rdb.Get(c.Request.Context(), redisKey).Bytes()
+	if err != nil {
+		// Key baru saja dihapus karena request pertama gagal, minta client mencoba lagi
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Request sedang diproses, silakan coba lagi"})
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		log.Printf("Invalid idempotency record %s: %v", redisKey, err)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid idempotency record"})
+		return
+	}
+
+	if record.RequestHash != requestHash {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "Idempotency-Key sudah dipakai untuk request yang berbeda",
+		})
+		return
+	}
+
+	if !record.Done {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "Request sedang diproses, silakan coba lagi"})
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
+
+func storeIdempotentResponse(ctx context.Context, rdb *redis.Client, redisKey, requestHash string, recorder *responseRecorder) {
+	status := recorder.Status()
+
+	// Error server tidak disimpan supaya client bisa retry dengan key yang sama
+	if status >= http.StatusInternalServerError {
+		releaseIdempotencyKey(ctx, rdb, redisKey)
+		return
+	}
+
+	record, err := json.Marshal(idempotencyRecord{
+		RequestHash: requestHash,
+		Done:        true,
+		Status:      status,
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        recorder.body.Bytes(),
+	})
+	if err != nil {
+		log.Printf("Failed to marshal idempotency record: %v", err)
+		releaseIdempotencyKey(ctx, rdb, redisKey)
+		return
+	}
+
+	if err := rdb.Set(ctx, redisKey, record, idempotencyTTL).Err(); err != nil {
+		log.Printf("Failed to store idempotency record %s: %v", redisKey, err)
+	}
+}
+
+func releaseIdempotencyKey(ctx context.Context, rdb *redis.Client, redisKey string) {
+	if err := rdb.Del(ctx, redisKey).Err(); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", redisKey, err)
+	}
+}
+
+func hashRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,206 @@
+package middlewares
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeRedis adalah server RESP minimal untuk perintah yang dipakai Idempotency:
+// SET (EX/PX/NX), GET, DEL dan PEXPIRE
+type fakeRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func newFakeRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedis{values: map[string]string{}, expires: map[string]time.Time{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	t.Cleanup(func() {
+		rdb.Close()
+		listener.Close()
+	})
+	return rdb
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := ""
+	if len(args) > 1 {
+		key = args[1]
+	}
+	if expiry, ok := f.expires[key]; ok && time.Now().After(expiry) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "set":
+		var ttl time.Duration
+		nx := false
+		for i := 3; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case "nx":
+				nx = true
+			case "ex", "px":
+				n, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(n) * time.Millisecond
+				if strings.ToLower(args[i]) == "ex" {
+					ttl = time.Duration(n) * time.Second
+				}
+				i++
+			}
+		}
+		if _, exists := f.values[key]; nx && exists {
+			return "$-1\r\n"
+		}
+		f.values[key] = args[2]
+		delete(f.expires, key)
+		if ttl > 0 {
+			f.expires[key] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "get":
+		value, ok := f.values[key]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "del":
+		_, ok := f.values[key]
+		delete(f.values, key)
+		delete(f.expires, key)
+		if ok {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "pexpire":
+		if _, ok := f.values[key]; !ok {
+			return ":0\r\n"
+		}
+		n, _ := strconv.Atoi(args[2])
+		f.expires[key] = time.Now().Add(time.Duration(n) * time.Millisecond)
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestIdempotencyLockOutlivesTTL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	previous := idempotencyLockTTL
+	idempotencyLockTTL = 150 * time.Millisecond
+	t.Cleanup(func() { idempotencyLockTTL = previous })
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	r := gin.New()
+	r.POST("/api/create-transaction", Idempotency(newFakeRedis(t)), func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			// Lebih lama dari beberapa kali idempotencyLockTTL, misal charge gateway yang lambat
+			time.Sleep(4 * idempotencyLockTTL)
+		}
+		c.JSON(http.StatusCreated, gin.H{"order_id": "ARV-1"})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/create-transaction", strings.NewReader(`{"product_id":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyHeader, "key-1")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- send() }()
+	<-started
+
+	// Request ulang setelah TTL awal lock lewat, request pertama masih berjalan
+	time.Sleep(2 * idempotencyLockTTL)
+	if w := send(); w.Code != http.StatusConflict {
+		t.Fatalf("request ulang saat diproses = %d, want 409", w.Code)
+	}
+
+	if w := <-first; w.Code != http.StatusCreated {
+		t.Fatalf("request pertama = %d, want 201", w.Code)
+	}
+
+	// Setelah selesai, response disimpan dan diputar ulang, lock tidak menimpa TTL response
+	time.Sleep(2 * idempotencyLockTTL)
+	w := send()
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("request ulang setelah selesai = %d, replayed = %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler dipanggil %d kali, want 1", got)
+	}
+}
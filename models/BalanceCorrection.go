@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// BalanceCorrection mencatat koreksi manual ProfilAplikasi.Saldo ke saldo Digiflazz
+type BalanceCorrection struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	SnapshotID uint `gorm:"column:snapshot_id;not null;index" json:"snapshot_id"`
+
+	OldSaldo   float64 `gorm:"column:old_saldo;not null" json:"old_saldo"`
+	NewSaldo   float64 `gorm:"column:new_saldo;not null" json:"new_saldo"`
+	Difference float64 `gorm:"column:difference;not null" json:"difference"`
+	Note       *string `gorm:"column:note;type:text" json:"note"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
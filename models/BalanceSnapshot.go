@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// BalanceSnapshot membandingkan saldo lokal (ProfilAplikasi.Saldo) dengan
+// saldo deposit di Digiflazz pada satu waktu
+type BalanceSnapshot struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	LocalSaldo  float64 `gorm:"column:local_saldo;not null" json:"local_saldo"`
+	RemoteSaldo float64 `gorm:"column:remote_saldo;not null" json:"remote_saldo"`
+	// local - remote, positif berarti saldo lokal lebih besar
+	Drift     float64 `gorm:"column:drift;not null" json:"drift"`
+	Threshold float64 `gorm:"column:threshold;not null" json:"threshold"`
+	Flagged   bool    `gorm:"column:flagged;not null;default:false;index" json:"flagged"`
+
+	// scheduled | manual
+	Source string `gorm:"column:source;size:20;not null" json:"source"`
+
+	CreatedAt time.Time `gorm:"column:created_at;index" json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// FulfilmentAttempt mencatat setiap percobaan pengiriman order ke supplier
+type FulfilmentAttempt struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	TransactionID uint   `gorm:"column:transaction_id;not null;index" json:"transaction_id"`
+	OrderID       string `gorm:"column:order_id;size:100;not null;index" json:"order_id"`
+
+	// Percobaan ke-n & index kandidat di routing table
+	Attempt   int    `gorm:"column:attempt;not null" json:"attempt"`
+	Candidate int    `gorm:"column:candidate;not null;default:0" json:"candidate"`
+	Provider  string `gorm:"column:provider;size:50;not null" json:"provider"`
+	SKU       string `gorm:"column:sku;size:255;not null" json:"sku"`
+	RefID     string `gorm:"column:ref_id;size:100;not null;uniqueIndex" json:"ref_id"`
+
+	// Harga beli kandidat ini, dipakai untuk debit & refund saldo
+	PurchasePrice decimal.Decimal `gorm:"column:purchase_price" json:"purchase_price"`
+
+	// pending | success | failed
+	Status  string  `gorm:"column:status;size:20;not null;index" json:"status"`
+	RC      *string `gorm:"column:rc;size:10" json:"rc"`
+	Message *string `gorm:"column:message;type:text" json:"message"`
+
+	Response datatypes.JSON `gorm:"column:response" json:"response"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 type PaymentMethod struct {
@@ -15,6 +17,11 @@ type PaymentMethod struct {
 	// cc | qris | bank_transfer | ewallet | cstore
 	Type string `gorm:"column:type;size:20;not null;index" json:"type"`
 
+	// midtrans | tripay
+	Gateway     string `gorm:"column:gateway;size:50;not null;default:'midtrans'" json:"gateway"`
+	// Kode channel di gateway, contoh Tripay: QRIS, BRIVA (kosong = pakai name)
+	GatewayCode string `gorm:"column:gateway_code;size:50" json:"gateway_code"`
+
 	Logo string `gorm:"column:logo;size:255" json:"logo"`
 	LogoPublicID string `gorm:"column:logo_public_id;size:255" json:"logo_public_id"`
 
@@ -24,3 +31,26 @@ type PaymentMethod struct {
 	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
+
+// CalculateFee menghitung biaya admin untuk nominal tertentu.
+// fee_type "nominal" hanya memakai NominalFee, "percentase" hanya PercentaseFee,
+// selain itu keduanya dijumlahkan. Hasil dibulatkan ke atas ke rupiah penuh.
+func (pm *PaymentMethod) CalculateFee(amount decimal.Decimal) decimal.Decimal {
+	nominal := decimal.NewFromFloat(pm.NominalFee)
+	percent := amount.Mul(decimal.NewFromFloat(pm.PercentaseFee)).Div(decimal.NewFromInt(100))
+
+	var fee decimal.Decimal
+	switch pm.FeeType {
+	case "nominal":
+		fee = nominal
+	case "percentase":
+		fee = percent
+	default:
+		fee = nominal.Add(percent)
+	}
+
+	if fee.IsNegative() {
+		return decimal.Zero
+	}
+	return fee.Ceil()
+}
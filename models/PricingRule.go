@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type PricingRule struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name string `gorm:"column:name;size:255;not null" json:"name"`
+
+	// global | category | brand | sku
+	Scope string `gorm:"column:scope;size:20;not null;index" json:"scope"`
+	// Nilai kategori / brand / buyer_sku_code yang dicocokkan (kosong untuk global)
+	Match string `gorm:"column:match_value;size:255;index" json:"match"`
+
+	// percent | flat
+	MarkupType  string  `gorm:"column:markup_type;size:20;not null" json:"markup_type"`
+	MarkupValue float64 `gorm:"column:markup_value;not null;default:0" json:"markup_value"`
+
+	// Pembulatan ke atas ke kelipatan ini, contoh 100 atau 500 (0 = tanpa pembulatan)
+	Rounding int64 `gorm:"column:rounding;not null;default:0" json:"rounding"`
+	// Margin minimum dalam rupiah di atas harga modal
+	MinMargin int64 `gorm:"column:min_margin;not null;default:0" json:"min_margin"`
+
+	// Jika ada beberapa rule dengan scope yang sama, priority tertinggi dipakai
+	Priority int `gorm:"column:priority;not null;default:0" json:"priority"`
+	// Tanpa default:true, GORM menyimpan false sebagai default saat Create.
+	// Default aktif diatur di controller (is_active kosong = aktif).
+	IsActive bool `gorm:"column:is_active;index" json:"is_active"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
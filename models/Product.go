@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -26,8 +27,10 @@ type Product struct {
 
 	// SKU dan Status
 	BuyerSkuCode       string `gorm:"column:buyer_sku_code;size:255;not null;uniqueIndex" json:"buyer_sku_code"`
-	BuyerProductStatus bool   `gorm:"column:buyer_product_status;not null;default:true" json:"buyer_product_status"`
-	SellerProductStatus bool  `gorm:"column:seller_product_status;not null;default:true" json:"seller_product_status"`
+	// Tanpa default:true, GORM mengganti false dengan default saat Create
+	// sehingga produk yang ditutup Digiflazz tersimpan sebagai tersedia
+	BuyerProductStatus bool   `gorm:"column:buyer_product_status;not null" json:"buyer_product_status"`
+	SellerProductStatus bool  `gorm:"column:seller_product_status;not null" json:"seller_product_status"`
 	UnlimitedStock      bool  `gorm:"column:unlimited_stock;not null;default:false" json:"unlimited_stock"`
 	Multi               bool  `gorm:"column:multi;not null;default:false" json:"multi"`
 
@@ -45,6 +48,9 @@ type Product struct {
 	Provider         string     `gorm:"column:provider;size:50;default:'digiflazz'" json:"provider"`
 	LastSyncAt       *time.Time `gorm:"column:last_sync_at" json:"last_sync_at"`
 	IsActive         bool       `gorm:"column:is_active;default:true" json:"is_active"`
+	// Diisi saat sync menonaktifkan SKU yang hilang dari price list, kosong
+	// berarti produk dinonaktifkan admin dan tidak diaktifkan lagi oleh sync
+	SyncRemovedAt    *time.Time `gorm:"column:sync_removed_at" json:"sync_removed_at"`
 	RetryCount       int        `gorm:"column:retry_count;default:0" json:"retry_count"`
 	MaxRetry         int        `gorm:"column:max_retry;default:3" json:"max_retry"`
 	RetryInterval    int        `gorm:"column:retry_interval;default:5" json:"retry_interval"` // menit
@@ -61,77 +67,87 @@ func (Product) TableName() string {
 
 // IsWithinCutoff mengecek apakah waktu sekarang dalam cutoff
 func (p *Product) IsWithinCutoff() bool {
-	now := time.Now()
-	currentTime := now.Format("15:04")
-	
-	start := p.StartCutOff
-	end := p.EndCutOff
-	
-	// Jika tidak ada cutoff (default), return false
-	if start == "00:00" && end == "23:59" {
+	return p.isWithinCutoffAt(time.Now())
+}
+
+// cutoffWindow mengembalikan start & end cutoff dalam menit sejak tengah malam.
+// Digiflazz mengirim "0:00" - "0:00" untuk produk tanpa cutoff, default lokal
+// "00:00" - "23:59" juga berarti tanpa cutoff.
+func (p *Product) cutoffWindow() (start, end int, ok bool) {
+	start, okStart := parseClock(p.StartCutOff)
+	end, okEnd := parseClock(p.EndCutOff)
+	if !okStart || !okEnd || start == end || (start == 0 && end == 23*60+59) {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+func (p *Product) isWithinCutoffAt(now time.Time) bool {
+	start, end, ok := p.cutoffWindow()
+	if !ok {
 		return false
 	}
-	
-	// Case 1: Cutoff melewati tengah malam (start > end)
+
+	current := now.Hour()*60 + now.Minute()
+
+	// Cutoff melewati tengah malam (start > end)
 	if start > end {
-		if currentTime >= start && currentTime <= "23:59" {
-			return true
-		}
-		if currentTime >= "00:00" && currentTime <= end {
-			return true
-		}
-	} else {
-		// Case 2: Cutoff dalam hari yang sama
-		if currentTime >= start && currentTime <= end {
-			return true
-		}
+		return current >= start || current <= end
 	}
-	
-	return false
+	// Cutoff dalam hari yang sama, end inklusif
+	return current >= start && current <= end
 }
 
-// GetNextAvailableTime mengembalikan waktu berikutnya bisa diproses
+// GetNextAvailableTime mengembalikan akhir cutoff yang sedang berjalan, nil jika tidak dalam cutoff
 func (p *Product) GetNextAvailableTime() *time.Time {
-	if !p.IsWithinCutoff() {
+	return p.nextAvailableTimeAt(time.Now())
+}
+
+func (p *Product) nextAvailableTimeAt(now time.Time) *time.Time {
+	if !p.isWithinCutoffAt(now) {
 		return nil
 	}
-	
-	now := time.Now()
-	currentTime := now.Format("15:04")
-	end := p.EndCutOff
-	
-	var endHour, endMin int
-	fmt.Sscanf(end, "%02d:%02d", &endHour, &endMin)
-	
-	nextTime := time.Date(
-		now.Year(), now.Month(), now.Day(),
-		endHour, endMin, 0, 0,
-		now.Location(),
-	)
-	
-	start := p.StartCutOff
-	
-	if start > end {
-		if currentTime >= start || currentTime <= end {
-			nextTime = nextTime.AddDate(0, 0, 1)
-		}
-	} else {
-		if currentTime < end {
-			// next time hari ini
-		} else {
-			var startHour, startMin int
-			fmt.Sscanf(start, "%02d:%02d", &startHour, &startMin)
-			nextTime = time.Date(
-				now.Year(), now.Month(), now.Day()+1,
-				startHour, startMin, 0, 0,
-				now.Location(),
-			)
-		}
+
+	start, end, _ := p.cutoffWindow()
+	current := now.Hour()*60 + now.Minute()
+
+	// Cutoff melewati tengah malam dan sekarang masih sebelum tengah malam:
+	// cutoff berakhir besok. Selain itu (termasuk lewat tengah malam) berakhir hari ini.
+	day := now.Day()
+	if start > end && current >= start {
+		day++
 	}
-	
+
+	nextTime := time.Date(now.Year(), now.Month(), day, end/60, end%60, 0, 0, now.Location())
 	return &nextTime
 }
 
+// parseClock mengubah "H:MM" atau "HH:MM" menjadi menit sejak tengah malam
+func parseClock(value string) (int, bool) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hour, &minute); err != nil {
+		return 0, false
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	return hour*60 + minute, true
+}
+
+// NextProcessTime mengembalikan waktu order bisa dikirim ke supplier: sekarang,
+// atau satu menit setelah end_cut_off karena IsWithinCutoff inklusif sampai menit tersebut
+func (p *Product) NextProcessTime() time.Time {
+	return p.nextProcessTimeAt(time.Now())
+}
+
+func (p *Product) nextProcessTimeAt(now time.Time) time.Time {
+	next := p.nextAvailableTimeAt(now)
+	if next == nil {
+		return now
+	}
+	return next.Add(time.Minute)
+}
+
 // IsStockAvailable mengecek ketersediaan stok
 func (p *Product) IsStockAvailable() bool {
 	if p.UnlimitedStock {
@@ -18,7 +18,7 @@ type ProductPasca struct {
 	Price        string `gorm:"column:price;size:255;not null" json:"price"`          // harga beli (Digiflazz)
 	SellingPrice string `gorm:"column:selling_price;size:255;not null" json:"selling_price"` // harga jual
 
-	BuyerSkuCode        string `gorm:"column:buyer_sku_code;size:255;not null" json:"buyer_sku_code"`
+	BuyerSkuCode        string `gorm:"column:buyer_sku_code;size:255;not null;uniqueIndex" json:"buyer_sku_code"`
 	BuyerProductStatus  bool `gorm:"column:buyer_product_status; not null" json:"buyer_product_status"`
 	SellerProductStatus bool   `gorm:"column:seller_product_status;not null" json:"seller_product_status"`
 
@@ -27,7 +27,14 @@ type ProductPasca struct {
 
 	Admin      string `gorm:"column:admin;size:255;not null" json:"admin"`
 	Commission string `gorm:"column:commission;size:255;not null" json:"commission"`
-	Desc       string `gorm:"column:desc;size:255;not null" json:"desc"`
+	Desc       string `gorm:"column:desc;type:text;not null" json:"desc"`
+
+	// false jika produk sudah tidak ada di price list Digiflazz
+	IsActive   bool       `gorm:"column:is_active;default:true;index" json:"is_active"`
+	LastSyncAt *time.Time `gorm:"column:last_sync_at" json:"last_sync_at"`
+	// Diisi saat sync menonaktifkan SKU yang hilang dari price list, kosong
+	// berarti produk dinonaktifkan admin dan tidak diaktifkan lagi oleh sync
+	SyncRemovedAt *time.Time `gorm:"column:sync_removed_at" json:"sync_removed_at"`
 
 	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ProductRoute memetakan produk ke daftar (provider, SKU) yang dicoba berurutan
+type ProductRoute struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	ProductID uint `gorm:"column:product_id;not null;index" json:"product_id"`
+	// Kecil = dicoba lebih dulu
+	Priority int    `gorm:"column:priority;not null;default:0" json:"priority"`
+	Provider string `gorm:"column:provider;size:50;not null" json:"provider"`
+	SKU      string `gorm:"column:sku;size:255;not null" json:"sku"`
+	// Tanpa default:true, GORM menyimpan false sebagai default saat Create
+	IsActive bool `gorm:"column:is_active" json:"is_active"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProductCutoff(t *testing.T) {
+	loc := time.FixedZone("WIB", 7*60*60)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, loc)
+	}
+
+	overnight := Product{StartCutOff: "23:00", EndCutOff: "01:00"}
+	sameDay := Product{StartCutOff: "13:00", EndCutOff: "15:30"}
+
+	tests := []struct {
+		name    string
+		product Product
+		now     time.Time
+		within  bool
+		process time.Time
+	}{
+		// Cutoff melewati tengah malam
+		{"overnight sebelum start", overnight, at(10, 22, 59), false, at(10, 22, 59)},
+		{"overnight tepat start", overnight, at(10, 23, 0), true, at(11, 1, 1)},
+		{"overnight sebelum tengah malam", overnight, at(10, 23, 30), true, at(11, 1, 1)},
+		{"overnight tepat tengah malam", overnight, at(11, 0, 0), true, at(11, 1, 1)},
+		{"overnight setelah tengah malam", overnight, at(11, 0, 30), true, at(11, 1, 1)},
+		{"overnight tepat end", overnight, at(11, 1, 0), true, at(11, 1, 1)},
+		{"overnight setelah end", overnight, at(11, 1, 1), false, at(11, 1, 1)},
+		{"overnight format Digiflazz", Product{StartCutOff: "23:45", EndCutOff: "0:15"}, at(11, 0, 5), true, at(11, 0, 16)},
+
+		// Cutoff dalam hari yang sama
+		{"same day sebelum start", sameDay, at(10, 12, 59), false, at(10, 12, 59)},
+		{"same day tepat start", sameDay, at(10, 13, 0), true, at(10, 15, 31)},
+		{"same day di tengah", sameDay, at(10, 14, 10), true, at(10, 15, 31)},
+		{"same day tepat end", sameDay, at(10, 15, 30), true, at(10, 15, 31)},
+		{"same day setelah end", sameDay, at(10, 15, 31), false, at(10, 15, 31)},
+		{"same day akhir bulan", Product{StartCutOff: "22:00", EndCutOff: "23:00"}, time.Date(2026, 3, 31, 22, 15, 0, 0, loc), true, time.Date(2026, 3, 31, 23, 1, 0, 0, loc)},
+
+		// Tanpa cutoff
+		{"default lokal", Product{StartCutOff: "00:00", EndCutOff: "23:59"}, at(10, 12, 0), false, at(10, 12, 0)},
+		{"default Digiflazz", Product{StartCutOff: "0:00", EndCutOff: "0:00"}, at(10, 0, 0), false, at(10, 0, 0)},
+		{"kosong", Product{}, at(10, 12, 0), false, at(10, 12, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.isWithinCutoffAt(tt.now); got != tt.within {
+				t.Errorf("isWithinCutoffAt(%s) = %v, want %v", tt.now.Format("15:04"), got, tt.within)
+			}
+			if got := tt.product.nextProcessTimeAt(tt.now); !got.Equal(tt.process) {
+				t.Errorf("nextProcessTimeAt(%s) = %s, want %s", tt.now.Format("02 15:04"), got.Format("02 15:04"), tt.process.Format("02 15:04"))
+			}
+		})
+	}
+}
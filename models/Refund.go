@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+type Refund struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Satu refund aktif per transaksi (dijaga refund.Request), refund yang
+	// ditolak tidak menghalangi refund baru untuk transaksi yang sama
+	TransactionID uint   `gorm:"column:transaction_id;not null;index:idx_refunds_transaction" json:"transaction_id"`
+	OrderID       string `gorm:"column:order_id;size:100;not null;index" json:"order_id"`
+
+	Amount    decimal.Decimal `gorm:"column:amount;type:decimal(15,2);not null" json:"amount"`
+	RefundKey string          `gorm:"column:refund_key;size:100;not null;uniqueIndex" json:"refund_key"`
+	Reason    string          `gorm:"column:reason;type:text" json:"reason"`
+
+	// gateway | manual
+	Method string `gorm:"column:method;size:20;not null;index" json:"method"`
+	// processing | pending_approval | approved | completed | rejected | failed
+	Status string `gorm:"column:status;size:20;not null;index" json:"status"`
+
+	// Rekening tujuan untuk refund manual
+	BankName      *string `gorm:"column:bank_name;size:100" json:"bank_name"`
+	AccountNumber *string `gorm:"column:account_number;size:50" json:"account_number"`
+	AccountName   *string `gorm:"column:account_name;size:255" json:"account_name"`
+	AdminNote     *string `gorm:"column:admin_note;type:text" json:"admin_note"`
+
+	GatewayResponse datatypes.JSON `gorm:"column:gateway_response" json:"gateway_response"`
+
+	ApprovedAt  *time.Time `gorm:"column:approved_at" json:"approved_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at" json:"completed_at"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// SyncReport mencatat hasil setiap sinkronisasi katalog Digiflazz
+type SyncReport struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// prepaid | postpaid
+	Kind string `gorm:"column:kind;size:20;not null;index" json:"kind"`
+	// scheduled | manual
+	Trigger string `gorm:"column:trigger_by;size:20;not null" json:"trigger"`
+
+	Total         int `gorm:"column:total;not null;default:0" json:"total"`
+	Created       int `gorm:"column:created_count;not null;default:0" json:"created"`
+	PriceChanged  int `gorm:"column:price_changed_count;not null;default:0" json:"price_changed"`
+	StatusChanged int `gorm:"column:status_changed_count;not null;default:0" json:"status_changed"`
+	Removed       int `gorm:"column:removed_count;not null;default:0" json:"removed"`
+	Unchanged     int `gorm:"column:unchanged_count;not null;default:0" json:"unchanged"`
+
+	// Daftar perubahan per SKU
+	Changes datatypes.JSON `gorm:"column:changes" json:"changes"`
+	Error   *string        `gorm:"column:error;type:text" json:"error"`
+
+	StartedAt  time.Time `gorm:"column:started_at;index" json:"started_at"`
+	FinishedAt time.Time `gorm:"column:finished_at" json:"finished_at"`
+	DurationMs int64     `gorm:"column:duration_ms" json:"duration_ms"`
+	// Waktu ambil price list & waktu tulis ke database
+	FetchMs int64 `gorm:"column:fetch_ms" json:"fetch_ms"`
+	WriteMs int64 `gorm:"column:write_ms" json:"write_ms"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
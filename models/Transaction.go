@@ -15,10 +15,15 @@ type Transaction struct {
 	// User & Product Info
 	UserID      *uint  `gorm:"column:user_id;index" json:"user_id"`
 	ProductID   *uint  `gorm:"column:product_id" json:"product_id"`
+	// Produk pilihan pembeli, ProductID berisi seller yang dipilih saat checkout
+	RequestedProductID *uint `gorm:"column:requested_product_id;index" json:"requested_product_id"`
 	ProductName *string `gorm:"column:product_name" json:"product_name"`
 	ProductType *string `gorm:"column:product_type;index" json:"product_type"`
 	CustomerNo  string  `gorm:"column:customer_no;index;not null" json:"customer_no"`
 	BuyerSkuCode string `gorm:"column:buyer_sku_code;not null" json:"buyer_sku_code"`
+	// Audit pemilihan seller saat checkout (SELLER_POLICY & skor seller terpilih)
+	SellerPolicy *string  `gorm:"column:seller_policy;size:20" json:"seller_policy"`
+	SellerScore  *float64 `gorm:"column:seller_score" json:"seller_score"`
 
 	// Transaction IDs
 	OrderID       string  `gorm:"column:order_id;unique;not null" json:"order_id"`
@@ -29,10 +34,14 @@ type Transaction struct {
 	SellingPrice decimal.Decimal `gorm:"column:selling_price" json:"selling_price"`
 	PurchasePrice decimal.Decimal `gorm:"column:purchase_price" json:"purchase_price"`
 
+	PaymentGateway    string  `gorm:"column:payment_gateway;size:50;not null;default:'midtrans'" json:"payment_gateway"`
 	PaymentType       *string `gorm:"column:payment_type" json:"payment_type"`
 	PaymentMethodName *string `gorm:"column:payment_method_name" json:"payment_method_name"`
 	MidtransResponse datatypes.JSON `gorm:"column:midtrans_response" json:"midtrans_response"`
 
+	// Dikirim ke Digiflazz dengan testing: true, saldo tidak dipotong
+	IsTest bool `gorm:"column:is_test;not null;default:false;index" json:"is_test"`
+
 	// Status
 	PaymentStatus    string  `gorm:"column:payment_status;default:pending;index" json:"payment_status"`
 	DigiflazzStatus  *string `gorm:"column:digiflazz_status;index" json:"digiflazz_status"`
@@ -59,12 +68,21 @@ type Transaction struct {
 	DigiflazzCallback datatypes.JSON `gorm:"column:digiflazz_callback" json:"digiflazz_callback"`
 	DigiflazzFlag     *string        `gorm:"column:digiflazz_flag" json:"digiflazz_flag"`
 
+	// Batas waktu pembayaran
+	ExpiredAt *time.Time `gorm:"column:expired_at;index" json:"expired_at"`
+
 	// Retry & Timing
 	RetryAt          *time.Time `gorm:"column:retry_at" json:"retry_at"`
 	RetryCount       int        `gorm:"column:retry_count;default:0" json:"retry_count"`
 	LastErrorCode    *string    `gorm:"column:last_error_code;size:10" json:"last_error_code"`
 	SaldoDebitedAt   *time.Time `gorm:"column:saldo_debited_at" json:"saldo_debited_at"`
+	SaldoRefundedAt  *time.Time `gorm:"column:saldo_refunded_at" json:"saldo_refunded_at"`
 	DigiflazzSentAt  *time.Time `gorm:"column:digiflazz_sent_at" json:"digiflazz_sent_at"`
+	// Diisi jika order pending terlalu lama & perlu dicek admin
+	EscalatedAt      *time.Time `gorm:"column:escalated_at;index" json:"escalated_at"`
+
+	// Percobaan pengiriman ke supplier, terakhir = yang sedang berjalan
+	Attempts []FulfilmentAttempt `gorm:"foreignKey:TransactionID" json:"attempts,omitempty"`
 
 	// Timestamps
 	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
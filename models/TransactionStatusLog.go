@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// TransactionStatusLog mencatat setiap perpindahan status transaksi
+type TransactionStatusLog struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	TransactionID uint   `gorm:"column:transaction_id;not null;index" json:"transaction_id"`
+	OrderID       string `gorm:"column:order_id;size:100;not null;index" json:"order_id"`
+
+	// payment | fulfilment
+	Kind       string `gorm:"column:kind;size:20;not null" json:"kind"`
+	FromStatus string `gorm:"column:from_status;size:30" json:"from_status"`
+	ToStatus   string `gorm:"column:to_status;size:30;not null" json:"to_status"`
+
+	// gateway | payment_expire | customer | admin | topup_job | digiflazz_webhook | refund
+	Source string  `gorm:"column:source;size:50;not null;index" json:"source"`
+	Note   *string `gorm:"column:note;type:text" json:"note"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
@@ -0,0 +1,187 @@
+// orderid/orderid.go — generator order_id yang urut waktu dan bebas tabrakan
+package orderid
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultPrefix = "ORD"
+
+	reserveTTL   = 48 * time.Hour
+	maxAttempts  = 5
+	crockford    = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	entropyBytes = 10
+)
+
+var ErrExhausted = errors.New("orderid: gagal membuat order_id unik")
+
+// Default dipakai oleh controller, diinisialisasi lewat Init
+var Default *Generator
+
+// Generator membuat order_id berformat <PREFIX>-<ULID>.
+// ULID diurutkan berdasarkan waktu (milidetik) dan monotonic dalam satu proses.
+// Jika Redis tersedia, setiap ID di-reserve dengan SETNX sehingga unik lintas instance.
+type Generator struct {
+	rdb           *redis.Client
+	defaultPrefix string
+	prefixes      map[string]string
+
+	mu          sync.Mutex
+	lastMs      uint64
+	lastEntropy [entropyBytes]byte
+}
+
+func NewGenerator(rdb *redis.Client, defaultPrefix string, prefixes map[string]string) *Generator {
+	if defaultPrefix == "" {
+		defaultPrefix = DefaultPrefix
+	}
+	normalized := make(map[string]string, len(prefixes))
+	for storefront, prefix := range prefixes {
+		normalized[strings.ToLower(storefront)] = sanitizePrefix(prefix)
+	}
+	return &Generator{
+		rdb:           rdb,
+		defaultPrefix: sanitizePrefix(defaultPrefix),
+		prefixes:      normalized,
+	}
+}
+
+// Init membuat Default dari env:
+// ORDER_ID_PREFIX   = prefix default (ORD)
+// ORDER_ID_PREFIXES = prefix per storefront, contoh "arveshop:ARV,partner:PRT"
+func Init(rdb *redis.Client) {
+	Default = NewGenerator(rdb, os.Getenv("ORDER_ID_PREFIX"), parsePrefixes(os.Getenv("ORDER_ID_PREFIXES")))
+}
+
+// Prefix mengembalikan prefix untuk storefront, atau prefix default
+func (g *Generator) Prefix(storefront string) string {
+	if prefix, ok := g.prefixes[strings.ToLower(storefront)]; ok && prefix != "" {
+		return prefix
+	}
+	return g.defaultPrefix
+}
+
+// New membuat order_id baru dan memastikan belum pernah dipakai
+func (g *Generator) New(ctx context.Context, storefront string) (string, error) {
+	prefix := g.Prefix(storefront)
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		id, err := g.nextULID()
+		if err != nil {
+			return "", err
+		}
+		orderID := prefix + "-" + id
+
+		if g.rdb == nil {
+			return orderID, nil
+		}
+
+		ok, err := g.rdb.SetNX(ctx, "order_id:"+orderID, 1, reserveTTL).Result()
+		if err != nil {
+			return "", fmt.Errorf("orderid: reserve: %w", err)
+		}
+		if ok {
+			return orderID, nil
+		}
+	}
+
+	return "", ErrExhausted
+}
+
+// nextULID menghasilkan ULID (48 bit timestamp + 80 bit entropy).
+// ID dalam milidetik yang sama dibuat dengan menaikkan entropy sebelumnya.
+func (g *Generator) nextULID() (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms <= g.lastMs {
+		ms = g.lastMs
+		if !incrementEntropy(&g.lastEntropy) {
+			// Entropy habis dalam 1 ms, geser ke milidetik berikutnya
+			ms++
+			if _, err := rand.Read(g.lastEntropy[:]); err != nil {
+				return "", err
+			}
+		}
+	} else if _, err := rand.Read(g.lastEntropy[:]); err != nil {
+		return "", err
+	}
+	g.lastMs = ms
+
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(ms >> (40 - 8*i))
+	}
+	copy(raw[6:], g.lastEntropy[:])
+
+	return encodeULID(raw), nil
+}
+
+func incrementEntropy(e *[entropyBytes]byte) bool {
+	for i := entropyBytes - 1; i >= 0; i-- {
+		e[i]++
+		if e[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeULID meng-encode 128 bit ke 26 karakter Crockford base32
+func encodeULID(raw [16]byte) string {
+	var out [26]byte
+
+	// 130 bit output, 2 bit teratas selalu nol: karakter pertama memuat 3 bit
+	out[0] = crockford[raw[0]>>5]
+	bitBuf := uint32(raw[0] & 0x1F)
+	bitCount := uint(5)
+	pos := 1
+
+	for i := 1; i < 16; i++ {
+		bitBuf = bitBuf<<8 | uint32(raw[i])
+		bitCount += 8
+		for bitCount >= 5 {
+			bitCount -= 5
+			out[pos] = crockford[(bitBuf>>bitCount)&0x1F]
+			pos++
+		}
+	}
+
+	return string(out[:])
+}
+
+func parsePrefixes(raw string) map[string]string {
+	prefixes := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		storefront, prefix, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || storefront == "" || prefix == "" {
+			continue
+		}
+		prefixes[storefront] = prefix
+	}
+	return prefixes
+}
+
+func sanitizePrefix(prefix string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(prefix) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return DefaultPrefix
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package orderid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func isCrockford(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(crockford, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFormat(t *testing.T) {
+	g := NewGenerator(nil, "", map[string]string{"Arveshop": "arv", "partner": "p-r-t"})
+
+	tests := []struct {
+		storefront string
+		prefix     string
+	}{
+		{"", DefaultPrefix},
+		{"arveshop", "ARV"},
+		{"ARVESHOP", "ARV"},
+		{"partner", "PRT"},
+		{"lainnya", DefaultPrefix},
+	}
+	for _, tt := range tests {
+		id, err := g.New(context.Background(), tt.storefront)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix, ulid, ok := strings.Cut(id, "-")
+		if !ok || prefix != tt.prefix {
+			t.Errorf("New(%q) = %q, want prefix %s", tt.storefront, id, tt.prefix)
+		}
+		if len(ulid) != 26 || !isCrockford(ulid) {
+			t.Errorf("New(%q) = %q, ULID tidak valid", tt.storefront, id)
+		}
+		// Karakter pertama hanya memuat 3 bit
+		if ulid[0] > '7' {
+			t.Errorf("New(%q) = %q, karakter pertama > 7", tt.storefront, id)
+		}
+	}
+}
+
+func TestEncodeULID(t *testing.T) {
+	var zero, max [16]byte
+	for i := range max {
+		max[i] = 0xFF
+	}
+	if got := encodeULID(zero); got != "00000000000000000000000000" {
+		t.Errorf("encodeULID(0) = %s", got)
+	}
+	if got := encodeULID(max); got != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+		t.Errorf("encodeULID(max) = %s", got)
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	got := parsePrefixes(" arveshop:ARV, partner:PRT,rusak,:X,kosong:")
+	if len(got) != 2 || got["arveshop"] != "ARV" || got["partner"] != "PRT" {
+		t.Errorf("parsePrefixes() = %v", got)
+	}
+	if got := sanitizePrefix("!!"); got != DefaultPrefix {
+		t.Errorf("sanitizePrefix(!!) = %s, want %s", got, DefaultPrefix)
+	}
+}
+
+func TestMonotonic(t *testing.T) {
+	g := NewGenerator(nil, "", nil)
+
+	// Ribuan ID dalam beberapa milidetik yang sama tetap urut
+	previous := ""
+	for i := 0; i < 10000; i++ {
+		id, err := g.New(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= previous {
+			t.Fatalf("ID ke-%d %s tidak lebih besar dari %s", i, id, previous)
+		}
+		previous = id
+	}
+}
+
+func TestEntropyOverflow(t *testing.T) {
+	g := NewGenerator(nil, "", nil)
+
+	// Entropy sudah maksimum di milidetik yang akan datang
+	future := uint64(time.Now().Add(time.Hour).UnixMilli())
+	g.lastMs = future
+	for i := range g.lastEntropy {
+		g.lastEntropy[i] = 0xFF
+	}
+	before := encodeULID(func() (raw [16]byte) {
+		for i := 0; i < 6; i++ {
+			raw[i] = byte(future >> (40 - 8*i))
+		}
+		copy(raw[6:], g.lastEntropy[:])
+		return raw
+	}())
+
+	id, err := g.nextULID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.lastMs != future+1 {
+		t.Errorf("lastMs = %d, want %d", g.lastMs, future+1)
+	}
+	if id <= before {
+		t.Errorf("ID setelah overflow %s tidak lebih besar dari %s", id, before)
+	}
+}
+
+func TestUniqueConcurrent(t *testing.T) {
+	g := NewGenerator(nil, "", nil)
+
+	const workers, perWorker = 16, 2000
+	ids := make(chan string, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				id, err := g.New(context.Background(), "")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := make(map[string]bool, workers*perWorker)
+	for id := range ids {
+		if seen[id] {
+			t.Fatalf("order_id ganda: %s", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != workers*perWorker {
+		t.Errorf("jumlah ID = %d, want %d", len(seen), workers*perWorker)
+	}
+}
+
+func TestReserveError(t *testing.T) {
+	// Redis tidak bisa dihubungi, order_id tidak boleh dibuat tanpa reserve
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer rdb.Close()
+
+	g := NewGenerator(rdb, "", nil)
+	if _, err := g.New(context.Background(), ""); err == nil || errors.Is(err, ErrExhausted) {
+		t.Errorf("New() error = %v, want error reserve", err)
+	}
+}
@@ -0,0 +1,37 @@
+// payment/expiry.go — batas waktu pembayaran per tipe metode pembayaran
+package payment
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default batas waktu bayar per PaymentMethod.Type
+var defaultExpiry = map[string]time.Duration{
+	"qris":          15 * time.Minute,
+	"ewallet":       15 * time.Minute,
+	"bank_transfer": 24 * time.Hour,
+	"cstore":        24 * time.Hour,
+	"cc":            1 * time.Hour,
+}
+
+const fallbackExpiry = 24 * time.Hour
+
+// ExpiryFor mengembalikan batas waktu bayar untuk tipe metode pembayaran.
+// Bisa dioverride lewat env PAYMENT_EXPIRY_<TYPE>, contoh PAYMENT_EXPIRY_QRIS=10m.
+func ExpiryFor(paymentType string) time.Duration {
+	key := "PAYMENT_EXPIRY_" + strings.ToUpper(paymentType)
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("⚠️ %s tidak valid: %q", key, v)
+	}
+
+	if d, ok := defaultExpiry[strings.ToLower(paymentType)]; ok {
+		return d
+	}
+	return fallbackExpiry
+}
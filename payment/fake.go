@@ -0,0 +1,149 @@
+// payment/fake.go — gateway in-memory untuk testing & development
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// FakeGateway menyimpan transaksi di memory tanpa memanggil pihak ketiga.
+// Status bisa diubah lewat SetStatus untuk mensimulasikan pembayaran.
+type FakeGateway struct {
+	name string
+
+	mu      sync.Mutex
+	charges map[string]*Notification
+	refunds map[string][]RefundRequest
+}
+
+func NewFakeGateway(name string) *FakeGateway {
+	if name == "" {
+		name = "fake"
+	}
+	return &FakeGateway{
+		name:    name,
+		charges: map[string]*Notification{},
+		refunds: map[string][]RefundRequest{},
+	}
+}
+
+func (f *FakeGateway) Name() string {
+	return f.name
+}
+
+func (f *FakeGateway) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	if req.PaymentMethod == "" {
+		return nil, ErrUnsupportedMethod
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.charges[req.OrderID]; exists {
+		return nil, &GatewayError{Gateway: f.name, StatusCode: http.StatusConflict, Body: "order_id sudah dipakai"}
+	}
+
+	transactionID := fmt.Sprintf("%s-%d", f.name, len(f.charges)+1)
+	f.charges[req.OrderID] = &Notification{
+		OrderID:       req.OrderID,
+		TransactionID: transactionID,
+		PaymentType:   req.PaymentMethod,
+		Status:        StatusPending,
+		GatewayStatus: "pending",
+		StatusMessage: "Fake charge created",
+		GrossAmount:   req.GrossAmount,
+	}
+
+	response := map[string]interface{}{
+		"transaction_id": transactionID,
+		"order_id":       req.OrderID,
+		"status_message": "Fake charge created",
+	}
+	raw, _ := json.Marshal(response)
+
+	return &ChargeResult{
+		TransactionID: transactionID,
+		PaymentType:   req.PaymentMethod,
+		StatusMessage: "Fake charge created",
+		PaymentURL:    "https://fake.local/pay/" + req.OrderID,
+		Response:      response,
+		RawResponse:   raw,
+	}, nil
+}
+
+func (f *FakeGateway) Status(ctx context.Context, ref Reference) (*Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.charges[ref.OrderID]
+	if !ok {
+		return nil, &GatewayError{Gateway: f.name, StatusCode: http.StatusNotFound, Body: "transaction not found"}
+	}
+	copied := *n
+	return &copied, nil
+}
+
+func (f *FakeGateway) Cancel(ctx context.Context, ref Reference) (*Notification, error) {
+	return f.SetStatus(ref.OrderID, StatusCancelled, "cancel")
+}
+
+func (f *FakeGateway) Refund(ctx context.Context, ref Reference, req RefundRequest) (*RefundResult, error) {
+	if _, err := f.SetStatus(ref.OrderID, StatusRefunded, "refund"); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.refunds[ref.OrderID] = append(f.refunds[ref.OrderID], req)
+	f.mu.Unlock()
+
+	return &RefundResult{RefundKey: req.RefundKey, Status: StatusRefunded}, nil
+}
+
+// VerifyNotification menerima body berupa Notification dalam JSON, tanpa signature
+func (f *FakeGateway) VerifyNotification(body []byte, header http.Header) (*Notification, error) {
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, fmt.Errorf("fake: invalid notification: %w", err)
+	}
+	n.Raw = body
+	return &n, nil
+}
+
+// SetStatus mengubah status transaksi, dipakai untuk mensimulasikan pembayaran
+func (f *FakeGateway) SetStatus(orderID, status, gatewayStatus string) (*Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.charges[orderID]
+	if !ok {
+		return nil, &GatewayError{Gateway: f.name, StatusCode: http.StatusNotFound, Body: "transaction not found"}
+	}
+	n.Status = status
+	n.GatewayStatus = gatewayStatus
+	copied := *n
+	return &copied, nil
+}
+
+// Refunds mengembalikan refund yang pernah diminta untuk sebuah order
+func (f *FakeGateway) Refunds(orderID string) []RefundRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]RefundRequest(nil), f.refunds[orderID]...)
+}
+
+// Charged mengembalikan total gross amount yang pernah di-charge
+func (f *FakeGateway) Charged() decimal.Decimal {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	total := decimal.Zero
+	for _, n := range f.charges {
+		total = total.Add(n.GrossAmount)
+	}
+	return total
+}
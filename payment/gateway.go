@@ -0,0 +1,159 @@
+// payment/gateway.go — abstraksi payment gateway
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Status aplikasi yang disimpan di Transaction.PaymentStatus
+const (
+	StatusPending       = "pending"
+	StatusSettlement    = "settlement"
+	StatusFailed        = "failed"
+	StatusExpired       = "expired"
+	StatusCancelled     = "cancelled"
+	StatusRefundPending = "refund_pending"
+	StatusRefunded      = "refunded"
+	StatusPartialRefund = "partial_refund"
+	StatusUnknown       = "unknown"
+)
+
+const DefaultGateway = "midtrans"
+
+var (
+	ErrInvalidSignature  = errors.New("payment: signature tidak valid")
+	ErrUnsupportedMethod = errors.New("payment: metode pembayaran tidak didukung")
+	ErrNotSupported      = errors.New("payment: operasi tidak didukung gateway")
+	ErrGatewayNotFound   = errors.New("payment: gateway tidak terdaftar")
+)
+
+// GatewayError adalah response non-2xx dari gateway
+type GatewayError struct {
+	Gateway    string
+	StatusCode int
+	Body       string
+}
+
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("%s error (%d): %s", e.Gateway, e.StatusCode, e.Body)
+}
+
+// Item adalah satu baris item_details
+type Item struct {
+	ID       string
+	Name     string
+	Price    decimal.Decimal
+	Quantity int
+}
+
+type ChargeRequest struct {
+	OrderID       string
+	GrossAmount   decimal.Decimal
+	Items         []Item
+	PaymentMethod string // qris, gopay, shopeepay, bca, bni, ...
+	GatewayCode   string // kode channel di gateway (contoh Tripay: QRIS, BRIVA), opsional
+	CustomerPhone string
+	CustomerName  string // kosong = placeholder dari gateway
+	OrderTime     time.Time
+	Expiry        time.Duration // batas waktu bayar, 0 = default gateway
+}
+
+type ChargeResult struct {
+	TransactionID string
+	PaymentType   string
+	StatusMessage string
+	PaymentURL    string // URL QR / redirect atau nomor VA
+	Deeplink      string
+	Response      map[string]interface{}
+	RawResponse   []byte
+}
+
+// Reference mengidentifikasi transaksi di sisi gateway
+type Reference struct {
+	OrderID       string
+	TransactionID string
+}
+
+// Notification adalah status transaksi yang sudah dinormalisasi,
+// baik dari webhook maupun dari pengecekan status
+type Notification struct {
+	OrderID       string
+	TransactionID string
+	PaymentType   string
+	Status        string // status aplikasi (StatusSettlement, StatusFailed, ...)
+	GatewayStatus string // status asli dari gateway
+	StatusMessage string
+	GrossAmount   decimal.Decimal
+	Raw           []byte
+}
+
+// IsPaid true jika pembayaran sudah diterima
+func (n *Notification) IsPaid() bool {
+	return n.Status == StatusSettlement
+}
+
+type RefundRequest struct {
+	RefundKey string
+	Amount    decimal.Decimal
+	Reason    string
+}
+
+type RefundResult struct {
+	RefundKey   string
+	Status      string
+	RawResponse []byte
+}
+
+// Gateway adalah kontrak yang harus dipenuhi setiap payment gateway
+type Gateway interface {
+	Name() string
+	Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+	Status(ctx context.Context, ref Reference) (*Notification, error)
+	Cancel(ctx context.Context, ref Reference) (*Notification, error)
+	Refund(ctx context.Context, ref Reference, req RefundRequest) (*RefundResult, error)
+	VerifyNotification(body []byte, header http.Header) (*Notification, error)
+}
+
+// ProductionFromEnv true jika salah satu gateway memakai environment production
+// (MIDTRANS_ENV atau TRIPAY_ENV), artinya pembeli membayar dengan uang sungguhan
+func ProductionFromEnv() bool {
+	return os.Getenv("MIDTRANS_ENV") == "production" || os.Getenv("TRIPAY_ENV") == "production"
+}
+
+// ─── Registry ─────────────────────────────────────────────────────────────────
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Gateway{}
+)
+
+// Register mendaftarkan gateway berdasarkan Name()
+func Register(g Gateway) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[g.Name()] = g
+}
+
+// Get mengambil gateway, nama kosong berarti DefaultGateway
+func Get(name string) (Gateway, error) {
+	if name == "" {
+		name = DefaultGateway
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	g, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrGatewayNotFound, name)
+	}
+	return g, nil
+}
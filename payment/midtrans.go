@@ -0,0 +1,399 @@
+// payment/midtrans.go — implementasi Gateway untuk Midtrans Core API
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	midtransSandboxURL    = "https://api.sandbox.midtrans.com"
+	midtransProductionURL = "https://api.midtrans.com"
+)
+
+type MidtransConfig struct {
+	ServerKey string
+	BaseURL   string
+	AppURL    string // dipakai untuk callback_url e-wallet
+}
+
+type Midtrans struct {
+	cfg    MidtransConfig
+	client *http.Client
+}
+
+func NewMidtrans(cfg MidtransConfig) *Midtrans {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = midtransSandboxURL
+	}
+	return &Midtrans{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewMidtransFromEnv membaca MIDTRANS_SERVER_KEY, MIDTRANS_ENV dan APP_URL
+func NewMidtransFromEnv() *Midtrans {
+	baseURL := midtransSandboxURL
+	if os.Getenv("MIDTRANS_ENV") == "production" {
+		baseURL = midtransProductionURL
+	}
+	return NewMidtrans(MidtransConfig{
+		ServerKey: os.Getenv("MIDTRANS_SERVER_KEY"),
+		BaseURL:   baseURL,
+		AppURL:    os.Getenv("APP_URL"),
+	})
+}
+
+func (m *Midtrans) Name() string {
+	return "midtrans"
+}
+
+// MidtransNotification struct untuk menampung data dari Midtrans
+type MidtransNotification struct {
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	PaymentType       string `json:"payment_type"`
+	TransactionTime   string `json:"transaction_time"`
+	TransactionStatus string `json:"transaction_status"`
+	GrossAmount       string `json:"gross_amount"`
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	SignatureKey      string `json:"signature_key"`
+	MerchantID        string `json:"merchant_id"`
+
+	// Untuk VA
+	VaNumbers []struct {
+		Bank     string `json:"bank"`
+		VaNumber string `json:"va_number"`
+	} `json:"va_numbers,omitempty"`
+
+	// Untuk QRIS / E-Wallet
+	Actions []struct {
+		Name   string `json:"name"`
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"actions,omitempty"`
+
+	// Untuk Kartu Kredit
+	ApprovalCode string `json:"approval_code,omitempty"`
+	FraudStatus  string `json:"fraud_status,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+}
+
+// ─── Charge ───────────────────────────────────────────────────────────────────
+
+func (m *Midtrans) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	itemDetails := make([]map[string]interface{}, 0, len(req.Items))
+	for _, item := range req.Items {
+		itemDetails = append(itemDetails, map[string]interface{}{
+			"id":       item.ID,
+			"price":    int(item.Price.IntPart()),
+			"quantity": item.Quantity,
+			"name":     item.Name,
+		})
+	}
+
+	transactionData := map[string]interface{}{
+		"transaction_details": map[string]interface{}{
+			"order_id":     req.OrderID,
+			"gross_amount": int(req.GrossAmount.IntPart()),
+		},
+		"item_details": itemDetails,
+	}
+
+	paymentType := ""
+	switch req.PaymentMethod {
+	case "qris":
+		paymentType = "qris"
+		transactionData["qris"] = map[string]interface{}{
+			"acquirer": "gopay",
+		}
+
+	case "gopay":
+		paymentType = "gopay"
+		transactionData["gopay"] = map[string]interface{}{
+			"enable_callback": true,
+			"callback_url":    m.cfg.AppURL + "/api/callback/midtrans",
+		}
+
+	case "shopeepay":
+		paymentType = "shopeepay"
+		transactionData["shopeepay"] = map[string]interface{}{
+			"callback_url": m.cfg.AppURL + "/api/callback/midtrans",
+		}
+
+	case "bca", "bni", "bri", "permata", "mandiri", "cimb":
+		paymentType = "bank_transfer"
+		transactionData["bank_transfer"] = map[string]interface{}{
+			"bank": req.PaymentMethod,
+		}
+
+	default:
+		return nil, ErrUnsupportedMethod
+	}
+	transactionData["payment_type"] = paymentType
+
+	if req.Expiry > 0 {
+		orderTime := req.OrderTime
+		if orderTime.IsZero() {
+			orderTime = time.Now()
+		}
+		transactionData["custom_expiry"] = map[string]interface{}{
+			"order_time":      orderTime.Format("2006-01-02 15:04:05 -0700"),
+			"expiry_duration": int(req.Expiry.Minutes()),
+			"unit":            "minute",
+		}
+	}
+
+	body, err := m.do(ctx, http.MethodPost, "/v2/charge", transactionData)
+	if err != nil {
+		return nil, err
+	}
+
+	var responseData map[string]interface{}
+	if err := json.Unmarshal(body, &responseData); err != nil {
+		return nil, fmt.Errorf("midtrans: invalid response: %w", err)
+	}
+
+	transactionID, _ := responseData["transaction_id"].(string)
+	statusMessage, _ := responseData["status_message"].(string)
+
+	return &ChargeResult{
+		TransactionID: transactionID,
+		PaymentType:   paymentType,
+		StatusMessage: statusMessage,
+		PaymentURL:    getPaymentURLOrVA(responseData),
+		Deeplink:      getDeeplinkGopay(responseData),
+		Response:      responseData,
+		RawResponse:   body,
+	}, nil
+}
+
+// ─── Status / Cancel / Refund ─────────────────────────────────────────────────
+
+func (m *Midtrans) Status(ctx context.Context, ref Reference) (*Notification, error) {
+	body, err := m.do(ctx, http.MethodGet, "/v2/"+ref.OrderID+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.parseNotification(body)
+}
+
+func (m *Midtrans) Cancel(ctx context.Context, ref Reference) (*Notification, error) {
+	body, err := m.do(ctx, http.MethodPost, "/v2/"+ref.OrderID+"/cancel", nil)
+	if err != nil {
+		return nil, err
+	}
+	return m.parseNotification(body)
+}
+
+func (m *Midtrans) Refund(ctx context.Context, ref Reference, req RefundRequest) (*RefundResult, error) {
+	payload := map[string]interface{}{
+		"refund_key": req.RefundKey,
+		"amount":     int(req.Amount.IntPart()),
+		"reason":     req.Reason,
+	}
+
+	body, err := m.do(ctx, http.MethodPost, "/v2/"+ref.OrderID+"/refund/online/direct", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var notification MidtransNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("midtrans: invalid response: %w", err)
+	}
+
+	return &RefundResult{
+		RefundKey:   req.RefundKey,
+		Status:      mapMidtransStatus(notification.TransactionStatus),
+		RawResponse: body,
+	}, nil
+}
+
+// ─── Notification ─────────────────────────────────────────────────────────────
+
+func (m *Midtrans) VerifyNotification(body []byte, header http.Header) (*Notification, error) {
+	var notification MidtransNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("midtrans: invalid notification: %w", err)
+	}
+
+	if !m.validateSignature(notification) {
+		return nil, ErrInvalidSignature
+	}
+
+	return toNotification(notification, body), nil
+}
+
+// Validasi signature key dari Midtrans
+func (m *Midtrans) validateSignature(notification MidtransNotification) bool {
+	if m.cfg.ServerKey == "" {
+		log.Println("WARNING: MIDTRANS_SERVER_KEY not set, skipping signature validation")
+		return true
+	}
+
+	// Format: order_id + status_code + gross_amount + server_key
+	signatureString := notification.OrderID + notification.StatusCode +
+		notification.GrossAmount + m.cfg.ServerKey
+
+	hash := sha512.New()
+	hash.Write([]byte(signatureString))
+	expectedSignature := hex.EncodeToString(hash.Sum(nil))
+
+	return expectedSignature == notification.SignatureKey
+}
+
+func (m *Midtrans) parseNotification(body []byte) (*Notification, error) {
+	var notification MidtransNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("midtrans: invalid response: %w", err)
+	}
+	return toNotification(notification, body), nil
+}
+
+func toNotification(n MidtransNotification, raw []byte) *Notification {
+	// Midtrans format: "10000.00" atau "10000"
+	grossAmount, err := decimal.NewFromString(n.GrossAmount)
+	if err != nil {
+		grossAmount = decimal.Zero
+	}
+
+	return &Notification{
+		OrderID:       n.OrderID,
+		TransactionID: n.TransactionID,
+		PaymentType:   n.PaymentType,
+		Status:        mapMidtransStatus(n.TransactionStatus),
+		GatewayStatus: n.TransactionStatus,
+		StatusMessage: n.StatusMessage,
+		GrossAmount:   grossAmount,
+		Raw:           raw,
+	}
+}
+
+// Mapping status Midtrans ke status aplikasi
+func mapMidtransStatus(midtransStatus string) string {
+	switch midtransStatus {
+	case "capture", "settlement":
+		return StatusSettlement
+	case "pending":
+		return StatusPending
+	case "expire":
+		return StatusExpired
+	case "cancel":
+		return StatusCancelled
+	case "deny", "failure":
+		return StatusFailed
+	case "refund":
+		return StatusRefunded
+	case "partial_refund":
+		return StatusPartialRefund
+	default:
+		return StatusUnknown
+	}
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func (m *Midtrans) do(ctx context.Context, method, path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("midtrans: encode request: %w", err)
+		}
+		// Log request untuk debugging
+		log.Printf("Midtrans Request %s %s: %s", method, path, string(jsonData))
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, m.cfg.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.SetBasicAuth(m.cfg.ServerKey, "")
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("midtrans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	// Log response untuk debugging
+	log.Printf("Midtrans Response (%d): %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return nil, &GatewayError{Gateway: m.Name(), StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return body, nil
+}
+
+func getPaymentURLOrVA(data map[string]interface{}) string {
+	// QRIS / e-wallet
+	if actions, ok := data["actions"].([]interface{}); ok {
+		for _, a := range actions {
+			if action, ok := a.(map[string]interface{}); ok {
+				if name, ok := action["name"].(string); ok && name == "generate-qr-code" {
+					if url, ok := action["url"].(string); ok {
+						return url
+					}
+				}
+				// Fallback ke url pertama
+				if url, ok := action["url"].(string); ok {
+					return url
+				}
+			}
+		}
+	}
+
+	// VA
+	if vaNumbers, ok := data["va_numbers"].([]interface{}); ok {
+		if len(vaNumbers) > 0 {
+			if va, ok := vaNumbers[0].(map[string]interface{}); ok {
+				if number, ok := va["va_number"].(string); ok {
+					return number
+				}
+			}
+		}
+	}
+
+	// Redirect URL
+	if redirectURL, ok := data["redirect_url"].(string); ok {
+		return redirectURL
+	}
+
+	return ""
+}
+
+func getDeeplinkGopay(data map[string]interface{}) string {
+	if actions, ok := data["actions"].([]interface{}); ok {
+		for _, a := range actions {
+			if action, ok := a.(map[string]interface{}); ok {
+				if name, ok := action["name"].(string); ok && name == "deeplink-redirect" {
+					if url, ok := action["url"].(string); ok {
+						return url
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package payment
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func midtransBody(t *testing.T, serverKey, status string, mutate func(map[string]string)) []byte {
+	t.Helper()
+	body := map[string]string{
+		"order_id":           "ARV-20260101-0001",
+		"status_code":        "200",
+		"gross_amount":       "10150.00",
+		"transaction_id":     "9aed5972-5b6a-401e-894b-a32c91ed1a3a",
+		"transaction_status": status,
+		"payment_type":       "qris",
+		"status_message":     "midtrans payment notification",
+	}
+	sum := sha512.Sum512([]byte(body["order_id"] + body["status_code"] + body["gross_amount"] + serverKey))
+	body["signature_key"] = hex.EncodeToString(sum[:])
+	if mutate != nil {
+		mutate(body)
+	}
+
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestMidtransVerifyNotification(t *testing.T) {
+	m := NewMidtrans(MidtransConfig{ServerKey: "SB-Mid-server-test"})
+
+	t.Run("signature valid", func(t *testing.T) {
+		n, err := m.VerifyNotification(midtransBody(t, "SB-Mid-server-test", "settlement", nil), nil)
+		if err != nil {
+			t.Fatalf("VerifyNotification() error = %v", err)
+		}
+		if n.OrderID != "ARV-20260101-0001" || n.Status != StatusSettlement || n.GatewayStatus != "settlement" {
+			t.Errorf("notification = %+v", n)
+		}
+		if !n.GrossAmount.Equal(decimal.NewFromInt(10150)) {
+			t.Errorf("gross amount = %s, want 10150", n.GrossAmount)
+		}
+		if !n.IsPaid() {
+			t.Error("settlement harus dianggap sudah dibayar")
+		}
+	})
+
+	rejected := []struct {
+		name string
+		body []byte
+	}{
+		{"server key lain", midtransBody(t, "SB-Mid-server-other", "settlement", nil)},
+		{"gross amount diubah", midtransBody(t, "SB-Mid-server-test", "settlement", func(b map[string]string) {
+			b["gross_amount"] = "1.00"
+		})},
+		{"order id diubah", midtransBody(t, "SB-Mid-server-test", "settlement", func(b map[string]string) {
+			b["order_id"] = "ARV-20260101-0002"
+		})},
+		{"tanpa signature", midtransBody(t, "SB-Mid-server-test", "settlement", func(b map[string]string) {
+			delete(b, "signature_key")
+		})},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.VerifyNotification(tt.body, nil); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyNotification() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+
+	t.Run("body bukan JSON", func(t *testing.T) {
+		if _, err := m.VerifyNotification([]byte("not json"), nil); err == nil || errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifyNotification() error = %v, want decode error", err)
+		}
+	})
+}
+
+func TestMapMidtransStatus(t *testing.T) {
+	tests := map[string]string{
+		"capture":        StatusSettlement,
+		"settlement":     StatusSettlement,
+		"pending":        StatusPending,
+		"expire":         StatusExpired,
+		"cancel":         StatusCancelled,
+		"deny":           StatusFailed,
+		"failure":        StatusFailed,
+		"refund":         StatusRefunded,
+		"partial_refund": StatusPartialRefund,
+		"authorize":      StatusUnknown,
+		"":               StatusUnknown,
+	}
+	for gatewayStatus, want := range tests {
+		if got := mapMidtransStatus(gatewayStatus); got != want {
+			t.Errorf("mapMidtransStatus(%q) = %q, want %q", gatewayStatus, got, want)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+// payment/tripay.go — implementasi Gateway untuk Tripay (closed payment)
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	tripaySandboxURL    = "https://tripay.co.id/api-sandbox"
+	tripayProductionURL = "https://tripay.co.id/api"
+
+	// Dipakai jika nama pelanggan tidak diketahui (Tripay mewajibkan customer_name)
+	tripayDefaultCustomerName = "Pelanggan ArveShop"
+)
+
+type TripayConfig struct {
+	APIKey        string
+	PrivateKey    string
+	MerchantCode  string
+	BaseURL       string
+	AppURL        string // dipakai untuk callback_url & return_url
+	CustomerEmail string // Tripay mewajibkan email pelanggan
+}
+
+type Tripay struct {
+	cfg    TripayConfig
+	client *http.Client
+}
+
+func NewTripay(cfg TripayConfig) *Tripay {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = tripaySandboxURL
+	}
+	if cfg.CustomerEmail == "" {
+		cfg.CustomerEmail = "customer@arveshop.id"
+	}
+	return &Tripay{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewTripayFromEnv membaca TRIPAY_API_KEY, TRIPAY_PRIVATE_KEY, TRIPAY_MERCHANT_CODE,
+// TRIPAY_ENV, TRIPAY_CUSTOMER_EMAIL dan APP_URL
+func NewTripayFromEnv() *Tripay {
+	baseURL := tripaySandboxURL
+	if os.Getenv("TRIPAY_ENV") == "production" {
+		baseURL = tripayProductionURL
+	}
+	return NewTripay(TripayConfig{
+		APIKey:        os.Getenv("TRIPAY_API_KEY"),
+		PrivateKey:    os.Getenv("TRIPAY_PRIVATE_KEY"),
+		MerchantCode:  os.Getenv("TRIPAY_MERCHANT_CODE"),
+		BaseURL:       baseURL,
+		AppURL:        os.Getenv("APP_URL"),
+		CustomerEmail: os.Getenv("TRIPAY_CUSTOMER_EMAIL"),
+	})
+}
+
+func (t *Tripay) Name() string {
+	return "tripay"
+}
+
+// Validate memastikan kredensial lengkap. Tanpa private key signature callback
+// dihitung dengan key kosong sehingga callback PAID bisa dipalsukan.
+func (t *Tripay) Validate() error {
+	var missing []string
+	if t.cfg.APIKey == "" {
+		missing = append(missing, "TRIPAY_API_KEY")
+	}
+	if t.cfg.PrivateKey == "" {
+		missing = append(missing, "TRIPAY_PRIVATE_KEY")
+	}
+	if t.cfg.MerchantCode == "" {
+		missing = append(missing, "TRIPAY_MERCHANT_CODE")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("tripay: %s belum diisi", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// TripayTransaction adalah data transaksi dari response create/detail
+type TripayTransaction struct {
+	Reference     string `json:"reference"`
+	MerchantRef   string `json:"merchant_ref"`
+	PaymentMethod string `json:"payment_method"`
+	PaymentName   string `json:"payment_name"`
+	Amount        int64  `json:"amount"`
+	PayCode       string `json:"pay_code"`
+	PayURL        string `json:"pay_url"`
+	CheckoutURL   string `json:"checkout_url"`
+	QRURL         string `json:"qr_url"`
+	Status        string `json:"status"`
+	Note          string `json:"note"`
+	ExpiredTime   int64  `json:"expired_time"`
+}
+
+type tripayResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// TripayCallback adalah body callback dari Tripay
+type TripayCallback struct {
+	Reference         string `json:"reference"`
+	MerchantRef       string `json:"merchant_ref"`
+	PaymentMethod     string `json:"payment_method"`
+	PaymentMethodCode string `json:"payment_method_code"`
+	TotalAmount       int64  `json:"total_amount"`
+	FeeMerchant       int64  `json:"fee_merchant"`
+	FeeCustomer       int64  `json:"fee_customer"`
+	TotalFee          int64  `json:"total_fee"`
+	AmountReceived    int64  `json:"amount_received"`
+	IsClosedPayment   int    `json:"is_closed_payment"`
+	Status            string `json:"status"`
+	PaidAt            *int64 `json:"paid_at"`
+	Note              string `json:"note"`
+}
+
+// ─── Charge ───────────────────────────────────────────────────────────────────
+
+func (t *Tripay) Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error) {
+	method := req.GatewayCode
+	if method == "" {
+		method = strings.ToUpper(req.PaymentMethod)
+	}
+	if method == "" {
+		return nil, ErrUnsupportedMethod
+	}
+
+	amount := req.GrossAmount.IntPart()
+
+	orderItems := make([]map[string]interface{}, 0, len(req.Items))
+	for _, item := range req.Items {
+		orderItems = append(orderItems, map[string]interface{}{
+			"sku":      item.ID,
+			"name":     item.Name,
+			"price":    item.Price.IntPart(),
+			"quantity": item.Quantity,
+		})
+	}
+
+	customerName := strings.TrimSpace(req.CustomerName)
+	if customerName == "" {
+		customerName = tripayDefaultCustomerName
+	}
+
+	payload := map[string]interface{}{
+		"method":         method,
+		"merchant_ref":   req.OrderID,
+		"amount":         amount,
+		"customer_name":  customerName,
+		"customer_email": t.cfg.CustomerEmail,
+		"customer_phone": req.CustomerPhone,
+		"order_items":    orderItems,
+		"callback_url":   t.cfg.AppURL + "/api/webhook/tripay",
+		"signature":      t.sign(t.cfg.MerchantCode + req.OrderID + fmt.Sprintf("%d", amount)),
+	}
+
+	if req.Expiry > 0 {
+		orderTime := req.OrderTime
+		if orderTime.IsZero() {
+			orderTime = time.Now()
+		}
+		payload["expired_time"] = orderTime.Add(req.Expiry).Unix()
+	}
+
+	body, err := t.do(ctx, http.MethodPost, "/transaction/create", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	trx, err := t.decodeTransaction(body)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentURL := trx.QRURL
+	if paymentURL == "" {
+		paymentURL = trx.PayCode
+	}
+	if paymentURL == "" {
+		paymentURL = trx.CheckoutURL
+	}
+
+	var response map[string]interface{}
+	json.Unmarshal(body, &response)
+
+	return &ChargeResult{
+		TransactionID: trx.Reference,
+		PaymentType:   trx.PaymentMethod,
+		StatusMessage: trx.Status,
+		PaymentURL:    paymentURL,
+		Deeplink:      trx.PayURL,
+		Response:      response,
+		RawResponse:   body,
+	}, nil
+}
+
+// ─── Status / Cancel / Refund ─────────────────────────────────────────────────
+
+func (t *Tripay) Status(ctx context.Context, ref Reference) (*Notification, error) {
+	if ref.TransactionID == "" {
+		return nil, fmt.Errorf("tripay: reference kosong untuk order %s", ref.OrderID)
+	}
+
+	body, err := t.do(ctx, http.MethodGet, "/transaction/detail?reference="+url.QueryEscape(ref.TransactionID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	trx, err := t.decodeTransaction(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Notification{
+		OrderID:       trx.MerchantRef,
+		TransactionID: trx.Reference,
+		PaymentType:   trx.PaymentMethod,
+		Status:        mapTripayStatus(trx.Status),
+		GatewayStatus: trx.Status,
+		StatusMessage: trx.Note,
+		GrossAmount:   decimal.NewFromInt(trx.Amount),
+		Raw:           body,
+	}, nil
+}
+
+// Cancel tidak tersedia di Tripay, transaksi closed payment hanya bisa expired
+func (t *Tripay) Cancel(ctx context.Context, ref Reference) (*Notification, error) {
+	return nil, ErrNotSupported
+}
+
+// Refund tidak tersedia lewat API Tripay
+func (t *Tripay) Refund(ctx context.Context, ref Reference, req RefundRequest) (*RefundResult, error) {
+	return nil, ErrNotSupported
+}
+
+// ─── Callback ─────────────────────────────────────────────────────────────────
+
+// VerifyNotification memvalidasi header X-Callback-Signature (HMAC-SHA256 body
+// dengan private key) dan X-Callback-Event
+func (t *Tripay) VerifyNotification(body []byte, header http.Header) (*Notification, error) {
+	if t.cfg.PrivateKey == "" {
+		log.Println("WARNING: TRIPAY_PRIVATE_KEY not set, rejecting callback")
+		return nil, ErrInvalidSignature
+	}
+
+	signature := header.Get("X-Callback-Signature")
+	if signature == "" || !hmac.Equal([]byte(t.sign(string(body))), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	if event := header.Get("X-Callback-Event"); event != "payment_status" {
+		return nil, fmt.Errorf("tripay: unsupported callback event %q", event)
+	}
+
+	var callback TripayCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		return nil, fmt.Errorf("tripay: invalid callback: %w", err)
+	}
+
+	return &Notification{
+		OrderID:       callback.MerchantRef,
+		TransactionID: callback.Reference,
+		PaymentType:   callback.PaymentMethodCode,
+		Status:        mapTripayStatus(callback.Status),
+		GatewayStatus: callback.Status,
+		StatusMessage: callback.Note,
+		GrossAmount:   decimal.NewFromInt(callback.TotalAmount),
+		Raw:           body,
+	}, nil
+}
+
+// Mapping status Tripay ke status aplikasi, sama dengan status Midtrans
+func mapTripayStatus(tripayStatus string) string {
+	switch strings.ToUpper(tripayStatus) {
+	case "PAID":
+		return StatusSettlement
+	case "UNPAID":
+		return StatusPending
+	case "EXPIRED":
+		return StatusExpired
+	case "FAILED":
+		return StatusFailed
+	case "REFUND":
+		return StatusRefunded
+	default:
+		return StatusUnknown
+	}
+}
+
+// ─── Helpers ──────────────────────────────────────────────────────────────────
+
+func (t *Tripay) sign(data string) string {
+	mac := hmac.New(sha256.New, []byte(t.cfg.PrivateKey))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (t *Tripay) decodeTransaction(body []byte) (*TripayTransaction, error) {
+	var resp tripayResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("tripay: invalid response: %w", err)
+	}
+	if !resp.Success {
+		return nil, &GatewayError{Gateway: t.Name(), StatusCode: http.StatusBadRequest, Body: resp.Message}
+	}
+
+	var trx TripayTransaction
+	if err := json.Unmarshal(resp.Data, &trx); err != nil {
+		return nil, fmt.Errorf("tripay: invalid transaction data: %w", err)
+	}
+	return &trx, nil
+}
+
+func (t *Tripay) do(ctx context.Context, method, path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("tripay: encode request: %w", err)
+		}
+		log.Printf("Tripay Request %s %s: %s", method, path, string(jsonData))
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, t.cfg.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+t.cfg.APIKey)
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("tripay: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	log.Printf("Tripay Response (%d): %s", resp.StatusCode, string(body))
+
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return nil, &GatewayError{Gateway: t.Name(), StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return body, nil
+}
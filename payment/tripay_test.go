@@ -0,0 +1,60 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+const tripayCallbackBody = `{"reference":"T0001000000000000006","merchant_ref":"ARV-20260101-0001","payment_method":"QRIS","payment_method_code":"QRIS","total_amount":10150,"status":"PAID","note":null}`
+
+func tripayHeader(privateKey, body string) http.Header {
+	mac := hmac.New(sha256.New, []byte(privateKey))
+	mac.Write([]byte(body))
+
+	header := http.Header{}
+	header.Set("X-Callback-Signature", hex.EncodeToString(mac.Sum(nil)))
+	header.Set("X-Callback-Event", "payment_status")
+	return header
+}
+
+func TestTripayValidate(t *testing.T) {
+	complete := TripayConfig{APIKey: "api", PrivateKey: "private", MerchantCode: "T0001"}
+	if err := NewTripay(complete).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	for name, cfg := range map[string]TripayConfig{
+		"tanpa private key":   {APIKey: "api", MerchantCode: "T0001"},
+		"tanpa merchant code": {APIKey: "api", PrivateKey: "private"},
+	} {
+		if err := NewTripay(cfg).Validate(); err == nil {
+			t.Errorf("%s: Validate() tidak mengembalikan error", name)
+		}
+	}
+}
+
+func TestTripayVerifyNotification(t *testing.T) {
+	tripay := NewTripay(TripayConfig{APIKey: "api", PrivateKey: "private", MerchantCode: "T0001"})
+
+	n, err := tripay.VerifyNotification([]byte(tripayCallbackBody), tripayHeader("private", tripayCallbackBody))
+	if err != nil {
+		t.Fatalf("VerifyNotification() error = %v", err)
+	}
+	if n.OrderID != "ARV-20260101-0001" || n.Status != StatusSettlement {
+		t.Errorf("notification = %+v", n)
+	}
+
+	if _, err := tripay.VerifyNotification([]byte(tripayCallbackBody), tripayHeader("other", tripayCallbackBody)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("key lain: error = %v, want ErrInvalidSignature", err)
+	}
+
+	// Tanpa private key callback bertanda tangan key kosong tidak boleh diterima
+	unconfigured := NewTripay(TripayConfig{APIKey: "api", MerchantCode: "T0001"})
+	if _, err := unconfigured.VerifyNotification([]byte(tripayCallbackBody), tripayHeader("", tripayCallbackBody)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("private key kosong: error = %v, want ErrInvalidSignature", err)
+	}
+}
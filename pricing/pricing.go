@@ -0,0 +1,183 @@
+// pricing/pricing.go — menghitung harga jual produk dari harga modal Digiflazz
+package pricing
+
+import (
+	"api-arveshop-go/models"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ScopeGlobal   = "global"
+	ScopeCategory = "category"
+	ScopeBrand    = "brand"
+	ScopeSKU      = "sku"
+
+	MarkupPercent = "percent"
+	MarkupFlat    = "flat"
+)
+
+var (
+	ErrInvalidScope      = errors.New("pricing: scope harus global, category, brand atau sku")
+	ErrInvalidMarkupType = errors.New("pricing: markup_type harus percent atau flat")
+	ErrMissingMatch      = errors.New("pricing: match wajib diisi untuk scope selain global")
+	ErrNegativeValue     = errors.New("pricing: nilai markup, rounding dan min_margin tidak boleh negatif")
+)
+
+// Urutan scope dari yang paling spesifik, rule SKU mengalahkan brand, dst.
+var scopeRank = map[string]int{
+	ScopeSKU:      4,
+	ScopeBrand:    3,
+	ScopeCategory: 2,
+	ScopeGlobal:   1,
+}
+
+// Validate memeriksa rule sebelum disimpan
+func Validate(rule *models.PricingRule) error {
+	if _, ok := scopeRank[rule.Scope]; !ok {
+		return ErrInvalidScope
+	}
+	if rule.MarkupType != MarkupPercent && rule.MarkupType != MarkupFlat {
+		return ErrInvalidMarkupType
+	}
+	if rule.Scope != ScopeGlobal && strings.TrimSpace(rule.Match) == "" {
+		return ErrMissingMatch
+	}
+	if rule.MarkupValue < 0 || rule.Rounding < 0 || rule.MinMargin < 0 {
+		return ErrNegativeValue
+	}
+	return nil
+}
+
+// Engine memilih rule yang paling spesifik untuk setiap produk
+type Engine struct {
+	rules []models.PricingRule
+}
+
+// NewEngine membuat engine dari daftar rule. Rule nonaktif diabaikan.
+func NewEngine(rules []models.PricingRule) *Engine {
+	active := make([]models.PricingRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.IsActive {
+			active = append(active, rule)
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		a, b := active[i], active[j]
+		if scopeRank[a.Scope] != scopeRank[b.Scope] {
+			return scopeRank[a.Scope] > scopeRank[b.Scope]
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.ID > b.ID
+	})
+
+	return &Engine{rules: active}
+}
+
+// Load membaca semua rule aktif dari database
+func Load(ctx context.Context, db *gorm.DB) (*Engine, error) {
+	var rules []models.PricingRule
+	if err := db.WithContext(ctx).Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return NewEngine(rules), nil
+}
+
+// Rules mengembalikan rule aktif sesuai urutan evaluasi
+func (e *Engine) Rules() []models.PricingRule {
+	return append([]models.PricingRule(nil), e.rules...)
+}
+
+// Match mencari rule untuk produk, nil jika tidak ada yang cocok
+func (e *Engine) Match(category, brand, sku string) *models.PricingRule {
+	for i := range e.rules {
+		rule := &e.rules[i]
+		switch rule.Scope {
+		case ScopeGlobal:
+			return rule
+		case ScopeCategory:
+			if strings.EqualFold(rule.Match, category) {
+				return rule
+			}
+		case ScopeBrand:
+			if strings.EqualFold(rule.Match, brand) {
+				return rule
+			}
+		case ScopeSKU:
+			if strings.EqualFold(rule.Match, sku) {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+// SellingPrice menghitung harga jual dari harga modal. Tanpa rule yang cocok,
+// harga jual sama dengan harga modal.
+func (e *Engine) SellingPrice(category, brand, sku string, cost int64) (int64, *models.PricingRule) {
+	rule := e.Match(category, brand, sku)
+	if rule == nil {
+		return cost, nil
+	}
+	return Apply(rule, cost), rule
+}
+
+// Apply menerapkan satu rule ke harga modal: markup, pembulatan ke atas,
+// lalu memastikan margin minimum terpenuhi
+func Apply(rule *models.PricingRule, cost int64) int64 {
+	price := cost
+	switch rule.MarkupType {
+	case MarkupPercent:
+		price = cost + int64(math.Ceil(float64(cost)*rule.MarkupValue/100))
+	case MarkupFlat:
+		price = cost + int64(math.Ceil(rule.MarkupValue))
+	}
+
+	price = roundUp(price, rule.Rounding)
+
+	if minPrice := cost + rule.MinMargin; price < minPrice {
+		price = roundUp(minPrice, rule.Rounding)
+	}
+	return price
+}
+
+func roundUp(price, step int64) int64 {
+	if step <= 0 || price%step == 0 {
+		return price
+	}
+	return (price/step + 1) * step
+}
+
+// ─── Reprice ──────────────────────────────────────────────────────────────────
+
+// Reprice menghitung ulang selling_price seluruh produk prepaid dan
+// mengembalikan jumlah produk yang harganya berubah
+func Reprice(ctx context.Context, db *gorm.DB, engine *Engine) (int, error) {
+	var products []models.Product
+	if err := db.WithContext(ctx).Where("product_type = ?", "prepaid").Find(&products).Error; err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, product := range products {
+		price, _ := engine.SellingPrice(product.Category, product.Brand, product.BuyerSkuCode, product.Price)
+		if price == product.SellingPrice {
+			continue
+		}
+		if err := db.WithContext(ctx).Model(&models.Product{}).
+			Where("id = ?", product.ID).
+			Update("selling_price", price).Error; err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, nil
+}
@@ -0,0 +1,110 @@
+package pricing
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/testdb"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.PricingRule
+		want error
+	}{
+		{"global percent", models.PricingRule{Scope: ScopeGlobal, MarkupType: MarkupPercent, MarkupValue: 5}, nil},
+		{"brand flat", models.PricingRule{Scope: ScopeBrand, Match: "TELKOMSEL", MarkupType: MarkupFlat, MarkupValue: 500}, nil},
+		{"scope tidak dikenal", models.PricingRule{Scope: "seller", MarkupType: MarkupFlat}, ErrInvalidScope},
+		{"markup tidak dikenal", models.PricingRule{Scope: ScopeGlobal, MarkupType: "fixed"}, ErrInvalidMarkupType},
+		{"match kosong", models.PricingRule{Scope: ScopeSKU, Match: " ", MarkupType: MarkupFlat}, ErrMissingMatch},
+		{"markup negatif", models.PricingRule{Scope: ScopeGlobal, MarkupType: MarkupFlat, MarkupValue: -1}, ErrNegativeValue},
+		{"min margin negatif", models.PricingRule{Scope: ScopeGlobal, MarkupType: MarkupFlat, MinMargin: -100}, ErrNegativeValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(&tt.rule); !errors.Is(err, tt.want) {
+				t.Errorf("Validate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		rule models.PricingRule
+		cost int64
+		want int64
+	}{
+		{"percent dibulatkan ke atas", models.PricingRule{MarkupType: MarkupPercent, MarkupValue: 3}, 5150, 5305},
+		{"flat", models.PricingRule{MarkupType: MarkupFlat, MarkupValue: 750}, 10120, 10870},
+		{"pembulatan ke kelipatan", models.PricingRule{MarkupType: MarkupFlat, MarkupValue: 750, Rounding: 500}, 10120, 11000},
+		{"kelipatan pas tidak berubah", models.PricingRule{MarkupType: MarkupFlat, MarkupValue: 500, Rounding: 500}, 10000, 10500},
+		{"margin minimum", models.PricingRule{MarkupType: MarkupPercent, MarkupValue: 1, MinMargin: 1000}, 5150, 6150},
+		{"margin minimum dibulatkan", models.PricingRule{MarkupType: MarkupPercent, MarkupValue: 1, MinMargin: 1000, Rounding: 100}, 5150, 6200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Apply(&tt.rule, tt.cost); got != tt.want {
+				t.Errorf("Apply(%d) = %d, want %d", tt.cost, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineMatch(t *testing.T) {
+	engine := NewEngine([]models.PricingRule{
+		{ID: 1, Name: "global", Scope: ScopeGlobal, MarkupType: MarkupFlat, IsActive: true},
+		{ID: 2, Name: "pulsa", Scope: ScopeCategory, Match: "Pulsa", MarkupType: MarkupFlat, IsActive: true},
+		{ID: 3, Name: "telkomsel", Scope: ScopeBrand, Match: "TELKOMSEL", MarkupType: MarkupFlat, IsActive: true},
+		{ID: 4, Name: "telkomsel prioritas", Scope: ScopeBrand, Match: "telkomsel", MarkupType: MarkupFlat, Priority: 10, IsActive: true},
+		{ID: 5, Name: "tsel5", Scope: ScopeSKU, Match: "TSEL5", MarkupType: MarkupFlat, IsActive: true},
+		{ID: 6, Name: "draft", Scope: ScopeSKU, Match: "TSEL10", MarkupType: MarkupFlat, IsActive: false},
+	})
+
+	tests := []struct {
+		category, brand, sku string
+		want                 string
+	}{
+		{"Pulsa", "TELKOMSEL", "TSEL5", "tsel5"},
+		{"Pulsa", "TELKOMSEL", "TSEL10", "telkomsel prioritas"},
+		{"Pulsa", "XL", "XL5", "pulsa"},
+		{"Data", "XL", "XLD1", "global"},
+	}
+	for _, tt := range tests {
+		rule := engine.Match(tt.category, tt.brand, tt.sku)
+		if rule == nil || rule.Name != tt.want {
+			t.Errorf("Match(%s, %s, %s) = %+v, want %s", tt.category, tt.brand, tt.sku, rule, tt.want)
+		}
+	}
+
+	if price, rule := NewEngine(nil).SellingPrice("Pulsa", "XL", "XL5", 5000); price != 5000 || rule != nil {
+		t.Errorf("tanpa rule: SellingPrice = %d, %+v, want harga modal", price, rule)
+	}
+}
+
+func TestReprice(t *testing.T) {
+	db, fake := testdb.Open(t)
+	fake.SetRows("products",
+		testdb.Row{"id": int64(1), "category": "Pulsa", "brand": "TELKOMSEL", "buyer_sku_code": "TSEL5", "price": int64(5150), "selling_price": int64(5150)},
+		testdb.Row{"id": int64(2), "category": "Pulsa", "brand": "TELKOMSEL", "buyer_sku_code": "TSEL10", "price": int64(10120), "selling_price": int64(10620)},
+	)
+	engine := NewEngine([]models.PricingRule{{Scope: ScopeGlobal, MarkupType: MarkupFlat, MarkupValue: 500, IsActive: true}})
+
+	changed, err := Reprice(context.Background(), db, engine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 {
+		t.Errorf("changed = %d, want 1", changed)
+	}
+
+	updates := fake.Execs("UPDATE `products`")
+	if len(updates) != 1 || !updates[0].Has(int64(5650)) {
+		t.Errorf("update products = %+v, want selling_price 5650 untuk TSEL5", updates)
+	}
+}
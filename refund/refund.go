@@ -0,0 +1,296 @@
+// refund/refund.go — pengembalian dana pembeli jika transaksi gagal setelah dibayar
+package refund
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/txstate"
+	"api-arveshop-go/websocket"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MethodGateway = "gateway"
+	MethodManual  = "manual"
+
+	StatusProcessing      = "processing"
+	StatusPendingApproval = "pending_approval"
+	StatusApproved        = "approved"
+	StatusCompleted       = "completed"
+	StatusRejected        = "rejected"
+	StatusFailed          = "failed"
+)
+
+var (
+	ErrNotRefundable  = errors.New("refund: transaksi belum dibayar atau sudah direfund")
+	ErrInvalidState   = errors.New("refund: status refund tidak sesuai")
+	ErrMissingAccount = errors.New("refund: rekening tujuan wajib diisi")
+)
+
+// Payment type yang bisa direfund langsung lewat API gateway
+var gatewayRefundable = map[string]bool{
+	"gopay":     true,
+	"shopeepay": true,
+	"qris":      true,
+}
+
+// SupportsGatewayRefund true jika payment type bisa direfund lewat API gateway
+func SupportsGatewayRefund(paymentType string) bool {
+	return gatewayRefundable[paymentType]
+}
+
+// Request mencatat refund untuk transaksi yang sudah settlement.
+// Pemanggilan berulang untuk transaksi yang sama mengembalikan refund yang sudah ada,
+// refund yang ditolak tidak dihitung sehingga transaksi bisa direfund lagi.
+// Refund lewat gateway dicoba untuk GoPay/ShopeePay/QRIS, selain itu
+// (atau jika gateway gagal) refund masuk antrian transfer manual.
+func Request(ctx context.Context, db *gorm.DB, transaction *models.Transaction, reason string) (*models.Refund, error) {
+	var refund models.Refund
+	var locked models.Transaction
+	created := false
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, transaction.ID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("transaction_id = ? AND status <> ?", locked.ID, StatusRejected).First(&refund).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if locked.PaymentStatus != payment.StatusSettlement {
+			return ErrNotRefundable
+		}
+
+		method := MethodManual
+		status := StatusPendingApproval
+		if locked.PaymentType != nil && SupportsGatewayRefund(*locked.PaymentType) {
+			method = MethodGateway
+			status = StatusProcessing
+		}
+
+		// refund_key unik per percobaan, refund ditolak sebelumnya memakai key lama
+		var previous int64
+		if err := tx.Model(&models.Refund{}).Where("transaction_id = ?", locked.ID).Count(&previous).Error; err != nil {
+			return err
+		}
+		refundKey := "RF-" + locked.OrderID
+		if previous > 0 {
+			refundKey = fmt.Sprintf("RF-%s-%d", locked.OrderID, previous+1)
+		}
+
+		refund = models.Refund{
+			TransactionID: locked.ID,
+			OrderID:       locked.OrderID,
+			Amount:        locked.GrossAmount,
+			RefundKey:     refundKey,
+			Reason:        reason,
+			Method:        method,
+			Status:        status,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+		created = true
+
+		_, err = txstate.Apply(ctx, tx, &locked, txstate.Change{
+			Payment: payment.StatusRefundPending,
+			Source:  txstate.SourceRefund,
+			Note:    reason,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !created {
+		return &refund, nil
+	}
+
+	slog.Info("💸 Refund dicatat", "order_id", refund.OrderID, "method", refund.Method, "amount", refund.Amount)
+
+	if refund.Method == MethodGateway {
+		processGatewayRefund(ctx, db, &locked, &refund)
+	}
+
+	websocket.BroadcastOrderStatus(refund.OrderID)
+	return &refund, nil
+}
+
+func processGatewayRefund(ctx context.Context, db *gorm.DB, transaction *models.Transaction, refund *models.Refund) {
+	gateway, err := payment.Get(transaction.PaymentGateway)
+	if err != nil {
+		moveToManual(db, refund, err)
+		return
+	}
+
+	ref := payment.Reference{OrderID: transaction.OrderID}
+	if transaction.TransactionID != nil {
+		ref.TransactionID = *transaction.TransactionID
+	}
+
+	result, err := gateway.Refund(ctx, ref, payment.RefundRequest{
+		RefundKey: refund.RefundKey,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+	if err != nil {
+		moveToManual(db, refund, err)
+		return
+	}
+
+	if err := complete(db, refund, StatusProcessing, map[string]any{"gateway_response": datatypes.JSON(result.RawResponse)}); err != nil {
+		slog.Error("Gagal menyimpan refund", "order_id", refund.OrderID, "err", err)
+	}
+}
+
+// moveToManual memindahkan refund gateway yang gagal ke antrian transfer manual
+func moveToManual(db *gorm.DB, refund *models.Refund, cause error) {
+	slog.Warn("Refund gateway gagal, dialihkan ke manual", "order_id", refund.OrderID, "err", cause)
+
+	note := "Refund gateway gagal: " + cause.Error()
+	if err := updateStatus(db, refund, []string{StatusProcessing}, map[string]any{
+		"method":     MethodManual,
+		"status":     StatusPendingApproval,
+		"admin_note": &note,
+	}); err != nil {
+		slog.Error("Gagal update refund", "order_id", refund.OrderID, "err", err)
+		return
+	}
+	refund.Method = MethodManual
+	refund.AdminNote = &note
+}
+
+// updateStatus mengubah refund hanya jika statusnya masih salah satu dari from,
+// sehingga aksi admin & gateway yang berjalan bersamaan tidak saling menimpa.
+// Jika status sudah berubah, refund.Status diisi status terbaru dan ErrInvalidState dikembalikan.
+func updateStatus(db *gorm.DB, refund *models.Refund, from []string, updates map[string]any) error {
+	result := db.Model(&models.Refund{}).Where("id = ? AND status IN ?", refund.ID, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var current models.Refund
+		if err := db.Select("status").First(&current, refund.ID).Error; err == nil {
+			refund.Status = current.Status
+		}
+		return ErrInvalidState
+	}
+
+	refund.Status = updates["status"].(string)
+	return nil
+}
+
+// Approve menyetujui refund manual beserta rekening tujuan transfer
+func Approve(db *gorm.DB, refund *models.Refund, bankName, accountNumber, accountName, note string) error {
+	if refund.Status != StatusPendingApproval {
+		return ErrInvalidState
+	}
+	if bankName == "" || accountNumber == "" || accountName == "" {
+		return ErrMissingAccount
+	}
+
+	now := time.Now()
+	updates := map[string]any{
+		"status":         StatusApproved,
+		"bank_name":      &bankName,
+		"account_number": &accountNumber,
+		"account_name":   &accountName,
+		"approved_at":    &now,
+	}
+	if note != "" {
+		updates["admin_note"] = &note
+	}
+	return updateStatus(db, refund, []string{StatusPendingApproval}, updates)
+}
+
+// Complete menandai refund manual sudah ditransfer
+func Complete(db *gorm.DB, refund *models.Refund, note string) error {
+	if refund.Status != StatusApproved {
+		return ErrInvalidState
+	}
+
+	updates := map[string]any{}
+	if note != "" {
+		updates["admin_note"] = &note
+	}
+	if err := complete(db, refund, StatusApproved, updates); err != nil {
+		return err
+	}
+
+	websocket.BroadcastOrderStatus(refund.OrderID)
+	return nil
+}
+
+// Reject menolak refund, transaksi kembali ke status settlement
+func Reject(db *gorm.DB, refund *models.Refund, note string) error {
+	if refund.Status != StatusPendingApproval && refund.Status != StatusApproved {
+		return ErrInvalidState
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := updateStatus(tx, refund, []string{StatusPendingApproval, StatusApproved}, map[string]any{
+			"status":     StatusRejected,
+			"admin_note": &note,
+		}); err != nil {
+			return err
+		}
+		_, err := txstate.Apply(tx.Statement.Context, tx, &models.Transaction{ID: refund.TransactionID, OrderID: refund.OrderID}, txstate.Change{
+			Payment: payment.StatusSettlement,
+			Source:  txstate.SourceRefund,
+			Note:    "Refund ditolak: " + note,
+		})
+		return err
+	})
+}
+
+// complete menandai refund selesai jika statusnya masih from
+func complete(db *gorm.DB, refund *models.Refund, from string, extra map[string]any) error {
+	now := time.Now()
+	updates := map[string]any{
+		"status":       StatusCompleted,
+		"completed_at": &now,
+	}
+	for k, v := range extra {
+		updates[k] = v
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := updateStatus(tx, refund, []string{from}, updates); err != nil {
+			return err
+		}
+		_, err := txstate.Apply(tx.Statement.Context, tx, &models.Transaction{ID: refund.TransactionID, OrderID: refund.OrderID}, txstate.Change{
+			Payment: payment.StatusRefunded,
+			Source:  txstate.SourceRefund,
+			Note:    "Refund " + refund.Method,
+		})
+		return err
+	})
+	if err == nil {
+		slog.Info("✅ Refund selesai", "order_id", refund.OrderID, "method", refund.Method)
+	}
+	return err
+}
+
+// DropTransactionUnique menghapus unique index transaction_id lama sebelum
+// AutoMigrate, supaya transaksi bisa direfund lagi setelah refund ditolak
+func DropTransactionUnique(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Refund{}) || !migrator.HasIndex(&models.Refund{}, "idx_refunds_transaction_id") {
+		return nil
+	}
+	return migrator.DropIndex(&models.Refund{}, "idx_refunds_transaction_id")
+}
@@ -0,0 +1,131 @@
+package refund
+
+import (
+	"api-arveshop-go/config"
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/testdb"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func useTestDB(t *testing.T) (*testdb.DB, func() *models.Refund) {
+	t.Helper()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	log.SetOutput(io.Discard)
+
+	db, fake := testdb.Open(t)
+	previous := config.DB
+	config.DB = db
+	t.Cleanup(func() { config.DB = previous })
+	return fake, func() *models.Refund {
+		return &models.Refund{ID: 1, TransactionID: 1, OrderID: "ARV-1", Method: MethodManual, Status: StatusPendingApproval}
+	}
+}
+
+// lostRace membuat UPDATE refunds tidak mengubah baris, seolah status sudah
+// diubah oleh admin lain di antara pembacaan dan penulisan
+func lostRace(fake *testdb.DB, current string) {
+	fake.SetRows("refunds", testdb.Row{"status": current})
+	fake.OnExec(func(exec testdb.Exec) int64 {
+		if strings.HasPrefix(exec.Query, "UPDATE `refunds`") {
+			return 0
+		}
+		return 1
+	})
+}
+
+func TestApproveGuardsStatus(t *testing.T) {
+	fake, newRefund := useTestDB(t)
+
+	record := newRefund()
+	if err := Approve(config.DB, record, "BCA", "123", "Budi", ""); err != nil {
+		t.Fatal(err)
+	}
+	updates := fake.Execs("UPDATE `refunds`")
+	if len(updates) != 1 || !strings.Contains(updates[0].Query, "status IN") || !updates[0].Has(StatusPendingApproval) {
+		t.Fatalf("update refunds = %+v, want dijaga status pending_approval", updates)
+	}
+	if record.Status != StatusApproved {
+		t.Errorf("status = %q, want approved", record.Status)
+	}
+
+	// Admin lain sudah menolak refund yang sama
+	fake.Reset()
+	lostRace(fake, StatusRejected)
+	record = newRefund()
+	if err := Approve(config.DB, record, "BCA", "123", "Budi", ""); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("Approve() error = %v, want ErrInvalidState", err)
+	}
+	if record.Status != StatusRejected {
+		t.Errorf("status = %q, want status terbaru rejected", record.Status)
+	}
+}
+
+func TestCompleteAndRejectGuardStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		run     func(*models.Refund) error
+	}{
+		{"complete setelah ditolak", StatusRejected, func(r *models.Refund) error {
+			r.Status = StatusApproved
+			return Complete(config.DB, r, "")
+		}},
+		{"reject setelah selesai", StatusCompleted, func(r *models.Refund) error {
+			return Reject(config.DB, r, "duplikat")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, newRefund := useTestDB(t)
+			lostRace(fake, tt.current)
+
+			record := newRefund()
+			if err := tt.run(record); !errors.Is(err, ErrInvalidState) {
+				t.Fatalf("error = %v, want ErrInvalidState", err)
+			}
+			// Status transaksi tidak ikut diubah
+			if updates := fake.Execs("UPDATE `transactions`"); len(updates) != 0 {
+				t.Errorf("update transactions = %d, want 0", len(updates))
+			}
+			if record.Status != tt.current {
+				t.Errorf("status = %q, want %q", record.Status, tt.current)
+			}
+		})
+	}
+}
+
+func TestRequestIgnoresRejected(t *testing.T) {
+	fake, _ := useTestDB(t)
+	fake.SetRows("transactions", testdb.Row{
+		"id": int64(1), "order_id": "ARV-1", "payment_status": payment.StatusSettlement, "gross_amount": "6000",
+	})
+
+	record, err := Request(context.Background(), config.DB, &models.Transaction{ID: 1, OrderID: "ARV-1"}, "Topup gagal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != StatusPendingApproval || record.RefundKey != "RF-ARV-1" {
+		t.Errorf("refund = %+v", record)
+	}
+
+	existing := false
+	for _, query := range fake.Queries("refunds") {
+		if strings.Contains(query.Query, "status <>") && query.Has(StatusRejected) {
+			existing = true
+		}
+	}
+	if !existing {
+		t.Errorf("cek refund yang sudah ada tidak mengecualikan rejected: %+v", fake.Queries("refunds"))
+	}
+	if inserts := fake.Execs("INSERT INTO `refunds`"); len(inserts) != 1 {
+		t.Errorf("insert refunds = %d, want 1", len(inserts))
+	}
+}
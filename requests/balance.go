@@ -0,0 +1,5 @@
+package requests
+
+type BalanceCorrectionRequest struct {
+	Note string `json:"note"`
+}
@@ -8,6 +8,8 @@ type CreatePaymentMethod struct {
 	PercentaseFee float64 `form:"percentase_fee"`
 	NominalFee float64 `form:"nominal_fee" `
 	Type string `form:"type" binding:"required"`
+	Gateway string `form:"gateway"`
+	GatewayCode string `form:"gateway_code"`
 	IsActive bool `form:"is_active"`
 	Logo *multipart.FileHeader `form:"logo"`	
 	LogoPublicID *string `form:"logo_public_id"`
@@ -19,6 +21,8 @@ type UpdatePaymentMethod struct {
 	PercentaseFee float64               `form:"percentase_fee"`
 	NominalFee    float64               `form:"nominal_fee"`
 	Type          string                `form:"type" binding:"required"`
+	Gateway       string                `form:"gateway"`
+	GatewayCode   string                `form:"gateway_code"`
 	IsActive      bool                  `form:"is_active"`
 	Logo          *multipart.FileHeader  `form:"logo"`
 	LogoPublicID  string                `form:"logo_public_id"`
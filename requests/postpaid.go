@@ -0,0 +1,13 @@
+package requests
+
+type PostpaidInquiryRequest struct {
+	BuyerSkuCode string `json:"buyer_sku_code" binding:"required"`
+	CustomerNo   string `json:"customer_no" binding:"required"`
+}
+
+type PostpaidCheckoutRequest struct {
+	InquiryID         string  `json:"inquiry_id" binding:"required"`
+	PaymentMethodName string  `json:"payment_method_name" binding:"required"`
+	Fee               float64 `json:"fee"`
+	WaPembeli         string  `json:"wa_pembeli" binding:"required"`
+}
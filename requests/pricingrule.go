@@ -0,0 +1,22 @@
+package requests
+
+type PricingRuleRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Scope       string  `json:"scope" binding:"required"`
+	Match       string  `json:"match"`
+	MarkupType  string  `json:"markup_type" binding:"required"`
+	MarkupValue float64 `json:"markup_value"`
+	Rounding    int64   `json:"rounding"`
+	MinMargin   int64   `json:"min_margin"`
+	Priority    int     `json:"priority"`
+	IsActive    *bool   `json:"is_active"`
+}
+
+// PricingPreviewRequest menampilkan harga hasil rule tanpa menyimpan.
+// Rule opsional ikut dievaluasi bersama rule aktif, berguna sebelum membuat rule baru.
+type PricingPreviewRequest struct {
+	Rule     *PricingRuleRequest `json:"rule"`
+	Category string              `json:"category"`
+	Brand    string              `json:"brand"`
+	SKU      string              `json:"sku"`
+}
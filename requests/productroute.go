@@ -0,0 +1,9 @@
+package requests
+
+type ProductRouteRequest struct {
+	ProductID uint   `json:"product_id" binding:"required"`
+	Priority  int    `json:"priority"`
+	Provider  string `json:"provider" binding:"required"`
+	SKU       string `json:"sku" binding:"required"`
+	IsActive  *bool  `json:"is_active"`
+}
@@ -0,0 +1,17 @@
+package requests
+
+type CreateRefundRequest struct {
+	OrderID string `json:"order_id" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+}
+
+type ApproveRefundRequest struct {
+	BankName      string `json:"bank_name" binding:"required"`
+	AccountNumber string `json:"account_number" binding:"required"`
+	AccountName   string `json:"account_name" binding:"required"`
+	Note          string `json:"note"`
+}
+
+type RefundNoteRequest struct {
+	Note string `json:"note"`
+}
@@ -8,8 +8,8 @@ type CreateServiceRequest struct {
     Slug       string                `form:"slug"`
     Logo       *multipart.FileHeader `form:"logo"`
     Icon       *multipart.FileHeader `form:"icon"`
-    IconPublicID *string             `form:"icon_public_id`
-    LogoPublicID *string             `form:"logo_public_id`
+    IconPublicID *string             `form:"icon_public_id"`
+    LogoPublicID *string             `form:"logo_public_id"`
     CategoryID uint                  `form:"category_id" binding:"required"`
 
     // Description
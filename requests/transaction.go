@@ -0,0 +1,8 @@
+package requests
+
+type UpdateTransactionStatusRequest struct {
+	OrderID         string `json:"order_id" binding:"required"`
+	PaymentStatus   string `json:"payment_status"`
+	DigiflazzStatus string `json:"digiflazz_status"`
+	Note            string `json:"note"`
+}
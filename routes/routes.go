@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"api-arveshop-go/config"
 	"api-arveshop-go/controllers"
+	"api-arveshop-go/middlewares"
 	"api-arveshop-go/websocket"
+	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,11 +15,14 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/api/categories", controllers.GetCategoriesHome)
 	r.GET("/api/services", controllers.GetServiceHome)
 	r.GET("/api/products/:slug", controllers.GetProductHome)
+	r.GET("/api/product-availability/:sku", controllers.GetProductAvailability)
 	r.GET("/api/service/:slug", controllers.GetPersonalService)
 	r.GET("/api/payment-method", controllers.GetPaymentMethodActive)
-	r.POST("/api/create-transaction", controllers.CreateTransaction)
-	r.POST("/api/get-products", controllers.GetProducts)
-	r.GET("/api/history/:order_id", controllers.GetHistory)	
+	r.POST("/api/create-transaction", middlewares.Idempotency(config.RDB), controllers.CreateTransaction)
+	r.POST("/api/postpaid/inquiry", controllers.PostpaidInquiry)
+	r.POST("/api/postpaid/checkout", middlewares.Idempotency(config.RDB), controllers.PostpaidCheckout)
+	r.GET("/api/history/:order_id", controllers.GetHistory)
+	r.POST("/api/transactions/:order_id/cancel", controllers.CancelTransaction)	
 
 	// start websocket manager
 	go websocket.Manager.Start()
@@ -24,15 +31,26 @@ func SetupRoutes(r *gin.Engine) {
 	r.GET("/ws", controllers.WebSocketConnection)
 	
 	r.GET("/api/payment-status/:order_id", controllers.GetStatusPayment)
-	r.POST("/api/payment-status/update", controllers.UpdatePaymentStatus)
 	r.POST("/api/webhook/midtrans", controllers.HandleMidtransWebhook)
+	r.POST("/api/webhook/tripay", controllers.HandleTripayWebhook)
 	r.POST("/api/webhook/digiflazz", controllers.HandleDigiflazzWebhook)
 
-	api := r.Group("/api/admin")
+	// Semua route admin wajib memakai ADMIN_API_KEY
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	if adminKey == "" {
+		log.Println("⚠️ ADMIN_API_KEY kosong, semua route /api/admin ditolak")
+	}
+
+	api := r.Group("/api/admin", middlewares.AdminAuth(adminKey))
 	{
 		api.GET("/users", controllers.GetUsers)
 		api.POST("/users", controllers.CreateUser)
 		api.GET("/application", controllers.GetApplicationSetting)
+		api.GET("/balance", controllers.GetBalance)
+		api.POST("/balance/check", controllers.CheckBalance)
+		api.POST("/balance/correct", controllers.CorrectBalance)
+		api.GET("/balance/snapshots", controllers.GetBalanceSnapshots)
+		api.GET("/balance/corrections", controllers.GetBalanceCorrections)
 		
 		api.GET("/categories", controllers.GetCategories)
 		api.POST("/categories", controllers.CreateCategory)
@@ -51,6 +69,35 @@ func SetupRoutes(r *gin.Engine) {
 		api.DELETE("/payment-method/:id", controllers.DeletePaymentMethod)
 
 		
+		api.POST("/products/sync", controllers.SyncProducts)
+		api.GET("/products/:id/sellers", controllers.GetSellerOptions)
+		api.GET("/sync-reports", controllers.GetSyncReports)
+		api.GET("/sync-reports/:id", controllers.GetSyncReport)
+
 		api.GET("/product-pasca", controllers.GetProductPasca)
+		api.POST("/product-pasca/sync", controllers.SyncProductPasca)
+
+		api.GET("/pricing-rules", controllers.GetPricingRules)
+		api.POST("/pricing-rules", controllers.CreatePricingRule)
+		api.PUT("/pricing-rules/:id", controllers.UpdatePricingRule)
+		api.DELETE("/pricing-rules/:id", controllers.DeletePricingRule)
+		api.POST("/pricing-rules/preview", controllers.PreviewPricing)
+		api.POST("/pricing-rules/apply", controllers.ApplyPricing)
+
+		api.POST("/transactions/status", controllers.ManualUpdateStatus)
+		api.GET("/transactions/escalated", controllers.GetEscalatedTransactions)
+		api.GET("/transactions/:order_id/status-logs", controllers.GetTransactionStatusLogs)
+		api.GET("/transactions/:order_id/attempts", controllers.GetFulfilmentAttempts)
+
+		api.GET("/product-routes", controllers.GetProductRoutes)
+		api.POST("/product-routes", controllers.CreateProductRoute)
+		api.PUT("/product-routes/:id", controllers.UpdateProductRoute)
+		api.DELETE("/product-routes/:id", controllers.DeleteProductRoute)
+
+		api.GET("/refunds", controllers.GetRefunds)
+		api.POST("/refunds", controllers.CreateRefund)
+		api.POST("/refunds/:id/approve", controllers.ApproveRefund)
+		api.POST("/refunds/:id/complete", controllers.CompleteRefund)
+		api.POST("/refunds/:id/reject", controllers.RejectRefund)
 	}
 }
@@ -0,0 +1,218 @@
+// seller/seller.go — pilih seller terbaik di antara produk yang setara
+package seller
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/supplier"
+	"context"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PolicyCheapest = "cheapest"
+	PolicyReliable = "reliable"
+	PolicyWeighted = "weighted"
+)
+
+// Policy menentukan cara memilih seller
+type Policy struct {
+	Name string
+	// Bobot harga untuk PolicyWeighted (0..1), sisanya bobot reliability
+	PriceWeight float64
+	// Rentang waktu FulfilmentAttempt yang dihitung untuk reliability
+	Window time.Duration
+}
+
+// PolicyFromEnv membaca SELLER_POLICY, SELLER_PRICE_WEIGHT & SELLER_RELIABILITY_WINDOW
+func PolicyFromEnv() Policy {
+	policy := Policy{Name: PolicyCheapest, PriceWeight: 0.5, Window: 7 * 24 * time.Hour}
+
+	switch name := os.Getenv("SELLER_POLICY"); name {
+	case "":
+	case PolicyCheapest, PolicyReliable, PolicyWeighted:
+		policy.Name = name
+	default:
+		slog.Warn("SELLER_POLICY tidak dikenal, pakai cheapest", "value", name)
+	}
+
+	if v, err := strconv.ParseFloat(os.Getenv("SELLER_PRICE_WEIGHT"), 64); err == nil && v >= 0 && v <= 1 {
+		policy.PriceWeight = v
+	}
+	if d, err := time.ParseDuration(os.Getenv("SELLER_RELIABILITY_WINDOW")); err == nil && d > 0 {
+		policy.Window = d
+	}
+	return policy
+}
+
+// GroupKey mengelompokkan produk setara: brand, type & nominal (nama produk) yang sama
+func GroupKey(p *models.Product) string {
+	return strings.ToLower(strings.TrimSpace(p.Brand)) + "|" +
+		strings.ToLower(strings.TrimSpace(p.Type)) + "|" +
+		strings.ToLower(strings.TrimSpace(p.ProductName))
+}
+
+// Option adalah satu seller kandidat beserta skornya
+type Option struct {
+	Product     models.Product `json:"product"`
+	Eligible    bool           `json:"eligible"`
+	Reliability float64        `json:"reliability"`
+	Score       float64        `json:"score"`
+}
+
+// Equivalents mengambil semua produk aktif yang satu grup dengan product
+func Equivalents(ctx context.Context, db *gorm.DB, product *models.Product) ([]models.Product, error) {
+	var products []models.Product
+	err := db.WithContext(ctx).
+		Where("brand = ? AND type = ? AND product_name = ? AND product_type = ?",
+			product.Brand, product.Type, product.ProductName, product.ProductType).
+		Where("is_active = ?", true).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	key := GroupKey(product)
+	group := make([]models.Product, 0, len(products))
+	for _, p := range products {
+		if GroupKey(&p) == key {
+			group = append(group, p)
+		}
+	}
+	return group, nil
+}
+
+// Options menilai semua seller setara sesuai policy, terbaik di urutan pertama
+func Options(ctx context.Context, db *gorm.DB, product *models.Product, policy Policy) ([]Option, error) {
+	products, err := Equivalents(ctx, db, product)
+	if err != nil {
+		return nil, err
+	}
+
+	reliability, err := reliabilityBySKU(ctx, db, products, policy.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	var minPrice int64
+	for _, p := range products {
+		if eligible(&p) && p.Price > 0 && (minPrice == 0 || p.Price < minPrice) {
+			minPrice = p.Price
+		}
+	}
+
+	options := make([]Option, 0, len(products))
+	for _, p := range products {
+		option := Option{
+			Product:     p,
+			Eligible:    eligible(&p),
+			Reliability: reliability[p.BuyerSkuCode],
+		}
+		option.Score = score(policy, &option, minPrice)
+		options = append(options, option)
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Product.Price != b.Product.Price {
+			return a.Product.Price < b.Product.Price
+		}
+		return a.Product.ID < b.Product.ID
+	})
+	return options, nil
+}
+
+// Select memilih seller terbaik untuk product beserta skornya. Jika tidak ada
+// seller setara yang bisa diproses sekarang, product sendiri dikembalikan.
+func Select(ctx context.Context, db *gorm.DB, product *models.Product, policy Policy) (*Option, error) {
+	options, err := Options(ctx, db, product, policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) > 0 && options[0].Eligible {
+		return &options[0], nil
+	}
+
+	for i := range options {
+		if options[i].Product.ID == product.ID {
+			return &options[i], nil
+		}
+	}
+	return &Option{Product: *product, Eligible: eligible(product)}, nil
+}
+
+// eligible: aktif di kedua sisi, di luar cutoff & stok tersedia
+func eligible(p *models.Product) bool {
+	return p.BuyerProductStatus && p.SellerProductStatus && p.CanBeProcessed()
+}
+
+func score(policy Policy, option *Option, minPrice int64) float64 {
+	if !option.Eligible {
+		return 0
+	}
+
+	// 1 untuk seller termurah, makin kecil makin mahal
+	priceScore := 0.0
+	if option.Product.Price > 0 && minPrice > 0 {
+		priceScore = float64(minPrice) / float64(option.Product.Price)
+	}
+
+	switch policy.Name {
+	case PolicyReliable:
+		return option.Reliability
+	case PolicyWeighted:
+		return policy.PriceWeight*priceScore + (1-policy.PriceWeight)*option.Reliability
+	default:
+		return priceScore
+	}
+}
+
+// reliabilityBySKU tingkat sukses tiap SKU dari FulfilmentAttempt yang sudah selesai.
+// Pakai (sukses+1)/(total+2) supaya SKU tanpa riwayat bernilai 0.5, bukan 0 atau 1.
+func reliabilityBySKU(ctx context.Context, db *gorm.DB, products []models.Product, window time.Duration) (map[string]float64, error) {
+	skus := make([]string, 0, len(products))
+	for _, p := range products {
+		skus = append(skus, p.BuyerSkuCode)
+	}
+
+	var rows []struct {
+		SKU     string
+		Success int64
+		Total   int64
+	}
+	if len(skus) > 0 {
+		err := db.WithContext(ctx).
+			Model(&models.FulfilmentAttempt{}).
+			Select("fulfilment_attempts.sku, SUM(CASE WHEN fulfilment_attempts.status = ? THEN 1 ELSE 0 END) AS success, COUNT(*) AS total", supplier.OutcomeSuccess).
+			// Order uji (mode testing Digiflazz) tidak mencerminkan performa seller
+			Joins("JOIN transactions ON transactions.id = fulfilment_attempts.transaction_id AND transactions.is_test = ?", false).
+			Where("fulfilment_attempts.sku IN ? AND fulfilment_attempts.status IN ?", skus, []string{supplier.OutcomeSuccess, supplier.OutcomeFailed}).
+			Where("fulfilment_attempts.created_at >= ?", time.Now().Add(-window)).
+			Group("fulfilment_attempts.sku").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reliability := make(map[string]float64, len(skus))
+	for _, sku := range skus {
+		reliability[sku] = 0.5
+	}
+	for _, row := range rows {
+		reliability[row.SKU] = float64(row.Success+1) / float64(row.Total+2)
+	}
+	return reliability, nil
+}
@@ -0,0 +1,127 @@
+package seller
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/testdb"
+	"context"
+	"math"
+	"testing"
+)
+
+func productRow(id int64, sku, name string, price int64, sellerOpen bool) testdb.Row {
+	return testdb.Row{
+		"id":                    id,
+		"brand":                 "TELKOMSEL",
+		"type":                  "Umum",
+		"product_name":          name,
+		"product_type":          "prepaid",
+		"seller_name":           "Seller " + sku,
+		"price":                 price,
+		"buyer_sku_code":        sku,
+		"buyer_product_status":  true,
+		"seller_product_status": sellerOpen,
+		"unlimited_stock":       true,
+		"start_cut_off":         "0:00",
+		"end_cut_off":           "0:00",
+		"is_active":             true,
+	}
+}
+
+func TestSelect(t *testing.T) {
+	product := models.Product{ID: 1, Brand: "TELKOMSEL", Type: "Umum", ProductName: "Telkomsel 5000", ProductType: "prepaid", BuyerSkuCode: "TSEL5A"}
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		products []testdb.Row
+		attempts []testdb.Row
+		wantSKU  string
+		wantOK   bool
+		wantScr  float64
+	}{
+		{
+			name:   "cheapest melewati seller tutup",
+			policy: Policy{Name: PolicyCheapest},
+			products: []testdb.Row{
+				productRow(1, "TSEL5A", "Telkomsel 5000", 5150, true),
+				productRow(2, "TSEL5B", "Telkomsel 5000", 5100, false),
+				productRow(3, "TSEL5C", "Telkomsel 5000", 5200, true),
+			},
+			wantSKU: "TSEL5A",
+			wantOK:  true,
+			wantScr: 1,
+		},
+		{
+			name:   "nominal lain bukan produk setara",
+			policy: Policy{Name: PolicyCheapest},
+			products: []testdb.Row{
+				productRow(1, "TSEL5A", "Telkomsel 5000", 5150, true),
+				productRow(4, "TSEL50", "Telkomsel 50000", 49500, true),
+			},
+			wantSKU: "TSEL5A",
+			wantOK:  true,
+			wantScr: 1,
+		},
+		{
+			name:   "reliable memilih tingkat sukses tertinggi",
+			policy: Policy{Name: PolicyReliable},
+			products: []testdb.Row{
+				productRow(1, "TSEL5A", "Telkomsel 5000", 5150, true),
+				productRow(3, "TSEL5C", "Telkomsel 5000", 5200, true),
+			},
+			attempts: []testdb.Row{
+				{"sku": "TSEL5A", "success": int64(2), "total": int64(10)},
+				{"sku": "TSEL5C", "success": int64(9), "total": int64(10)},
+			},
+			wantSKU: "TSEL5C",
+			wantOK:  true,
+			wantScr: 10.0 / 12,
+		},
+		{
+			name:   "tidak ada yang eligible, produk sendiri dikembalikan",
+			policy: Policy{Name: PolicyCheapest},
+			products: []testdb.Row{
+				productRow(1, "TSEL5A", "Telkomsel 5000", 5150, false),
+				productRow(2, "TSEL5B", "Telkomsel 5000", 5100, false),
+			},
+			wantSKU: "TSEL5A",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := testdb.Open(t)
+			fake.SetRows("products", tt.products...)
+			fake.SetRows("fulfilment_attempts", tt.attempts...)
+
+			selected, err := Select(context.Background(), db, &product, tt.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if selected.Product.BuyerSkuCode != tt.wantSKU || selected.Eligible != tt.wantOK {
+				t.Fatalf("Select() = %s eligible=%v, want %s eligible=%v",
+					selected.Product.BuyerSkuCode, selected.Eligible, tt.wantSKU, tt.wantOK)
+			}
+			if math.Abs(selected.Score-tt.wantScr) > 1e-9 {
+				t.Errorf("score = %v, want %v", selected.Score, tt.wantScr)
+			}
+		})
+	}
+}
+
+func TestScoreWeighted(t *testing.T) {
+	policy := Policy{Name: PolicyWeighted, PriceWeight: 0.25}
+	option := &Option{Product: models.Product{Price: 5200}, Eligible: true, Reliability: 0.8}
+
+	// 0.25 * 5000/5200 + 0.75 * 0.8
+	want := 0.25*5000/5200 + 0.75*0.8
+	if got := score(policy, option, 5000); math.Abs(got-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", got, want)
+	}
+
+	option.Eligible = false
+	if got := score(policy, option, 5000); got != 0 {
+		t.Errorf("score tidak eligible = %v, want 0", got)
+	}
+}
@@ -0,0 +1,99 @@
+// supplier/digiflazz.go — adapter Digiflazz untuk interface Supplier
+package supplier
+
+import (
+	"api-arveshop-go/digiflazz"
+	"context"
+)
+
+// rc Digiflazz yang menandakan seller/produk gangguan atau stok habis
+var digiflazzUnavailableRC = map[string]bool{
+	"53": true, "55": true, "62": true, "68": true, "71": true,
+}
+
+var digiflazzFailedRC = map[string]bool{
+	"40": true, "41": true, "42": true, "43": true, "44": true, "45": true,
+}
+
+var digiflazzRetryableRC = map[string]bool{
+	"06": true, "07": true, "08": true, "17": true, "39": true,
+}
+
+type Digiflazz struct {
+	client *digiflazz.Client
+}
+
+func NewDigiflazz(client *digiflazz.Client) *Digiflazz {
+	return &Digiflazz{client: client}
+}
+
+func (d *Digiflazz) Name() string { return DefaultProvider }
+
+func (d *Digiflazz) Topup(ctx context.Context, req Request) (*Result, error) {
+	call := d.client.Topup
+	if req.Postpaid {
+		call = d.client.PayPasca
+	}
+	return fromDigiflazzCall(call(ctx, digiflazzRequest(req)))
+}
+
+func (d *Digiflazz) Status(ctx context.Context, req Request) (*Result, error) {
+	call := d.client.Status
+	if req.Postpaid {
+		call = d.client.StatusPasca
+	}
+	return fromDigiflazzCall(call(ctx, digiflazzRequest(req)))
+}
+
+func digiflazzRequest(req Request) digiflazz.TransactionRequest {
+	return digiflazz.TransactionRequest{
+		BuyerSkuCode: req.SKU,
+		CustomerNo:   req.CustomerNo,
+		RefID:        req.RefID,
+		Testing:      req.Testing,
+	}
+}
+
+// Response tetap dikembalikan bersama error supaya raw-nya bisa disimpan
+func fromDigiflazzCall(trx *digiflazz.Transaction, err error) (*Result, error) {
+	if trx == nil {
+		return nil, err
+	}
+	return FromDigiflazz(trx), err
+}
+
+// FromDigiflazz menormalisasi response API maupun callback Digiflazz
+func FromDigiflazz(trx *digiflazz.Transaction) *Result {
+	return &Result{
+		RefID:   trx.RefID,
+		RC:      trx.RC,
+		Status:  trx.Status,
+		Message: trx.Message,
+		SN:      trx.SN,
+		Price:   trx.Price.Int64(),
+		Outcome: digiflazzOutcome(trx),
+		Raw:     []byte(trx.Raw),
+	}
+}
+
+func digiflazzOutcome(trx *digiflazz.Transaction) string {
+	switch {
+	case trx.RC == digiflazz.RCSuccess:
+		return OutcomeSuccess
+	case trx.RC == digiflazz.RCPending || trx.RC == "201":
+		return OutcomePending
+	case digiflazzFailedRC[trx.RC]:
+		return OutcomeFailed
+	case digiflazzUnavailableRC[trx.RC]:
+		return OutcomeUnavailable
+	case digiflazzRetryableRC[trx.RC]:
+		return OutcomeRetryable
+	case trx.IsSuccess():
+		return OutcomeSuccess
+	case trx.IsPending():
+		return OutcomePending
+	case trx.IsFailed():
+		return OutcomeFailed
+	}
+	return OutcomeUnknown
+}
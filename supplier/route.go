@@ -0,0 +1,56 @@
+// supplier/route.go — daftar kandidat (provider, SKU) untuk satu produk
+package supplier
+
+import (
+	"api-arveshop-go/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Candidate adalah satu pasangan provider & SKU yang bisa memenuhi order
+type Candidate struct {
+	Provider string `json:"provider"`
+	SKU      string `json:"sku"`
+}
+
+// Candidates mengembalikan kandidat sesuai urutan prioritas models.ProductRoute.
+// Produk tanpa route aktif hanya punya satu kandidat: provider produk & SKU order.
+func Candidates(ctx context.Context, db *gorm.DB, order *models.Transaction) ([]Candidate, error) {
+	primary := Candidate{Provider: DefaultProvider, SKU: order.BuyerSkuCode}
+
+	if order.ProductID != nil {
+		var product models.Product
+		if err := db.WithContext(ctx).Select("id", "provider").First(&product, *order.ProductID).Error; err == nil && product.Provider != "" {
+			primary.Provider = product.Provider
+		}
+	}
+
+	// Route dipasang pada produk pilihan pembeli, bukan seller yang dipilih
+	// saat checkout. Order lama tidak punya requested_product_id.
+	routeProductID := order.RequestedProductID
+	if routeProductID == nil {
+		routeProductID = order.ProductID
+	}
+	if routeProductID == nil {
+		return []Candidate{primary}, nil
+	}
+
+	var routes []models.ProductRoute
+	err := db.WithContext(ctx).
+		Where("product_id = ? AND is_active = ?", *routeProductID, true).
+		Order("priority ASC, id ASC").
+		Find(&routes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return []Candidate{primary}, nil
+	}
+
+	candidates := make([]Candidate, 0, len(routes))
+	for _, route := range routes {
+		candidates = append(candidates, Candidate{Provider: route.Provider, SKU: route.SKU})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,89 @@
+// supplier/supplier.go — abstraksi supplier produk untuk pengiriman order
+package supplier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const DefaultProvider = "digiflazz"
+
+// Hasil request ke supplier yang sudah dinormalisasi
+const (
+	OutcomeSuccess     = "success"
+	OutcomePending     = "pending"
+	OutcomeFailed      = "failed"      // gagal final untuk SKU ini
+	OutcomeUnavailable = "unavailable" // seller/produk gangguan, bisa pindah ke kandidat lain
+	OutcomeRetryable   = "retryable"
+	OutcomeUnknown     = "unknown"
+)
+
+var (
+	ErrSupplierNotFound = errors.New("supplier: provider tidak terdaftar")
+	ErrNotSupported     = errors.New("supplier: operasi tidak didukung provider")
+)
+
+type Request struct {
+	SKU        string `json:"sku"`
+	CustomerNo string `json:"customer_no"`
+	RefID      string `json:"ref_id"`
+	Postpaid   bool   `json:"postpaid,omitempty"` // bayar tagihan pascabayar, ref_id sama dengan saat inquiry
+	Testing    bool   `json:"testing,omitempty"`  // order uji, tidak boleh memotong deposit supplier
+}
+
+type Result struct {
+	RefID   string
+	RC      string
+	Status  string // status asli dari provider
+	Message string
+	SN      string
+	Price   int64
+	Outcome string
+	Raw     []byte
+}
+
+// ShouldFallback true jika order boleh dipindah ke kandidat berikutnya
+func (r *Result) ShouldFallback() bool {
+	return r.Outcome == OutcomeFailed || r.Outcome == OutcomeUnavailable
+}
+
+// Supplier adalah kontrak yang harus dipenuhi setiap provider
+type Supplier interface {
+	Name() string
+	// Topup mengirim order, rc gagal bukan error (lihat Result.Outcome)
+	Topup(ctx context.Context, req Request) (*Result, error)
+	// Status menanyakan ulang order dengan ref_id yang sama
+	Status(ctx context.Context, req Request) (*Result, error)
+}
+
+// ─── Registry ─────────────────────────────────────────────────────────────────
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Supplier{}
+)
+
+// Register mendaftarkan supplier berdasarkan Name()
+func Register(s Supplier) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[s.Name()] = s
+}
+
+// Get mengambil supplier, nama kosong berarti DefaultProvider
+func Get(name string) (Supplier, error) {
+	if name == "" {
+		name = DefaultProvider
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	s, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSupplierNotFound, name)
+	}
+	return s, nil
+}
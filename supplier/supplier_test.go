@@ -0,0 +1,113 @@
+package supplier
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/testdb"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type namedSupplier struct{ name string }
+
+func (s namedSupplier) Name() string { return s.name }
+func (s namedSupplier) Topup(context.Context, Request) (*Result, error) {
+	return nil, ErrNotSupported
+}
+func (s namedSupplier) Status(context.Context, Request) (*Result, error) {
+	return nil, ErrNotSupported
+}
+
+func TestRegistry(t *testing.T) {
+	Register(namedSupplier{name: "test-provider"})
+
+	if s, err := Get("test-provider"); err != nil || s.Name() != "test-provider" {
+		t.Errorf("Get(test-provider) = %v, %v", s, err)
+	}
+	if _, err := Get("tidak-ada"); !errors.Is(err, ErrSupplierNotFound) {
+		t.Errorf("Get(tidak-ada) error = %v, want ErrSupplierNotFound", err)
+	}
+}
+
+func TestShouldFallback(t *testing.T) {
+	for outcome, want := range map[string]bool{
+		OutcomeSuccess:     false,
+		OutcomePending:     false,
+		OutcomeFailed:      true,
+		OutcomeUnavailable: true,
+		OutcomeRetryable:   false,
+		OutcomeUnknown:     false,
+	} {
+		if got := (&Result{Outcome: outcome}).ShouldFallback(); got != want {
+			t.Errorf("ShouldFallback(%s) = %v, want %v", outcome, got, want)
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	productID := uint(7)
+	selectedID := uint(9)
+
+	tests := []struct {
+		name    string
+		order   models.Transaction
+		product []testdb.Row
+		routes  []testdb.Row
+		want    []Candidate
+	}{
+		{
+			name:  "tanpa produk",
+			order: models.Transaction{BuyerSkuCode: "TSEL5"},
+			want:  []Candidate{{Provider: DefaultProvider, SKU: "TSEL5"}},
+		},
+		{
+			name:    "tanpa route memakai provider produk",
+			order:   models.Transaction{ProductID: &productID, BuyerSkuCode: "TSEL5"},
+			product: []testdb.Row{{"id": int64(7), "provider": "other"}},
+			want:    []Candidate{{Provider: "other", SKU: "TSEL5"}},
+		},
+		{
+			name:    "route sesuai urutan",
+			order:   models.Transaction{ProductID: &productID, BuyerSkuCode: "TSEL5"},
+			product: []testdb.Row{{"id": int64(7), "provider": DefaultProvider}},
+			routes: []testdb.Row{
+				{"id": int64(1), "product_id": int64(7), "priority": int64(0), "provider": DefaultProvider, "sku": "TSEL5"},
+				{"id": int64(2), "product_id": int64(7), "priority": int64(1), "provider": DefaultProvider, "sku": "TSEL5B"},
+			},
+			want: []Candidate{{Provider: DefaultProvider, SKU: "TSEL5"}, {Provider: DefaultProvider, SKU: "TSEL5B"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := testdb.Open(t)
+			fake.SetRows("products", tt.product...)
+			fake.SetRows("product_routes", tt.routes...)
+
+			got, err := Candidates(context.Background(), db, &tt.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Candidates() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Seller lain dipilih saat checkout, route tetap diambil dari produk pilihan pembeli
+	t.Run("route dari produk pilihan pembeli", func(t *testing.T) {
+		db, fake := testdb.Open(t)
+		fake.SetRows("products", testdb.Row{"id": int64(9), "provider": DefaultProvider})
+
+		order := models.Transaction{ProductID: &selectedID, RequestedProductID: &productID, BuyerSkuCode: "TSEL5C"}
+		if _, err := Candidates(context.Background(), db, &order); err != nil {
+			t.Fatal(err)
+		}
+
+		queries := fake.Queries("product_routes")
+		if len(queries) != 1 || !queries[0].Has(int64(productID)) || queries[0].Has(int64(selectedID)) {
+			t.Errorf("query product_routes = %+v, want product_id %d", queries, productID)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+// testdb/testdb.go — database palsu untuk test tanpa MySQL
+//
+// Open membuat *gorm.DB (dialect MySQL) di atas driver database/sql yang
+// mencatat setiap statement yang mengubah data dan mengembalikan baris stub
+// untuk SELECT per tabel. DryRun hanya membangun SQL tanpa menjalankannya.
+package testdb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const driverName = "testdb"
+
+var (
+	registerOnce sync.Once
+	instances    sync.Map // nama koneksi → *DB
+	nextID       atomic.Int64
+)
+
+// Row adalah satu baris hasil SELECT, kolom → nilai
+type Row map[string]driver.Value
+
+// Exec adalah satu statement yang dijalankan beserta argumennya
+type Exec struct {
+	Query string
+	Args  []driver.Value
+}
+
+// Row memetakan kolom INSERT ke nilai baris pertama, nil untuk statement lain
+func (e Exec) Row() Row {
+	if !strings.HasPrefix(e.Query, "INSERT") {
+		return nil
+	}
+	start, end := strings.Index(e.Query, "("), strings.Index(e.Query, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	columns := strings.Split(e.Query[start+1:end], ",")
+	row := make(Row, len(columns))
+	for i, column := range columns {
+		if i >= len(e.Args) {
+			break
+		}
+		row[strings.Trim(column, "` ")] = e.Args[i]
+	}
+	return row
+}
+
+// Has true jika salah satu argumen sama dengan want (string & []byte disamakan)
+func (e Exec) Has(want driver.Value) bool {
+	for _, arg := range e.Args {
+		if b, ok := arg.([]byte); ok {
+			arg = string(b)
+		}
+		if arg == want {
+			return true
+		}
+	}
+	return false
+}
+
+// DB adalah state satu database palsu
+type DB struct {
+	mu      sync.Mutex
+	rows    map[string][]Row
+	execs   []Exec
+	queries []Exec
+	onExec  func(Exec) int64
+
+	latency    atomic.Int64
+	roundTrips atomic.Int64
+}
+
+// Open membuat database palsu baru, terpisah dari test lain
+func Open(tb testing.TB) (*gorm.DB, *DB) {
+	tb.Helper()
+	registerOnce.Do(func() { sql.Register(driverName, fakeDriver{}) })
+
+	name := fmt.Sprintf("db-%d", nextID.Add(1))
+	state := &DB{rows: map[string][]Row{}}
+	instances.Store(name, state)
+
+	sqlDB, err := sql.Open(driverName, name)
+	if err != nil {
+		tb.Fatalf("testdb: open driver: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{
+		Logger:               logger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		tb.Fatalf("testdb: open gorm: %v", err)
+	}
+
+	tb.Cleanup(func() {
+		sqlDB.Close()
+		instances.Delete(name)
+	})
+	return db, state
+}
+
+// DryRun membangun SQL tanpa koneksi ke database, baca hasilnya dari
+// Statement.SQL & Statement.Vars
+func DryRun(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "test:test@tcp(127.0.0.1:3306)/test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		tb.Fatalf("testdb: open dry run: %v", err)
+	}
+	return db
+}
+
+// SetRows mengganti baris yang dikembalikan untuk SELECT ... FROM `table`
+func (d *DB) SetRows(table string, rows ...Row) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows[table] = rows
+}
+
+// OnExec menentukan RowsAffected setiap statement, default 1
+func (d *DB) OnExec(fn func(Exec) int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onExec = fn
+}
+
+// SetLatency menahan setiap round trip (query, exec, begin, commit) selama latency
+func (d *DB) SetLatency(latency time.Duration) {
+	d.latency.Store(int64(latency))
+}
+
+// RoundTrips jumlah round trip sejak ResetRoundTrips
+func (d *DB) RoundTrips() int64 { return d.roundTrips.Load() }
+
+func (d *DB) ResetRoundTrips() { d.roundTrips.Store(0) }
+
+// Execs mengembalikan statement yang diawali prefix, misal "UPDATE `transactions`"
+func (d *DB) Execs(prefix string) []Exec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var execs []Exec
+	for _, exec := range d.execs {
+		if strings.HasPrefix(exec.Query, prefix) {
+			execs = append(execs, exec)
+		}
+	}
+	return execs
+}
+
+// Queries mengembalikan SELECT ke tabel table
+func (d *DB) Queries(table string) []Exec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var queries []Exec
+	for _, query := range d.queries {
+		if tableOf(query.Query) == table {
+			queries = append(queries, query)
+		}
+	}
+	return queries
+}
+
+// Reset menghapus baris stub & statement yang tercatat
+func (d *DB) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.rows = map[string][]Row{}
+	d.execs = nil
+	d.queries = nil
+	d.onExec = nil
+}
+
+func (d *DB) trip() {
+	d.roundTrips.Add(1)
+	if latency := time.Duration(d.latency.Load()); latency > 0 {
+		time.Sleep(latency)
+	}
+}
+
+func newExec(query string, args []driver.NamedValue) Exec {
+	exec := Exec{Query: query, Args: make([]driver.Value, len(args))}
+	for i, arg := range args {
+		exec.Args[i] = arg.Value
+	}
+	return exec
+}
+
+func (d *DB) exec(query string, args []driver.NamedValue) int64 {
+	exec := newExec(query, args)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.execs = append(d.execs, exec)
+	if d.onExec != nil {
+		return d.onExec(exec)
+	}
+	return 1
+}
+
+func (d *DB) query(query string, args []driver.NamedValue) *rows {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = append(d.queries, newExec(query, args))
+
+	stub := d.rows[tableOf(query)]
+	if len(stub) == 0 {
+		return &rows{}
+	}
+
+	// Kolom diurutkan supaya hasil scan tidak bergantung urutan map
+	columns := make([]string, 0, len(stub[0]))
+	for column := range stub[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	result := &rows{columns: columns}
+	for _, row := range stub {
+		values := make([]driver.Value, len(columns))
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		result.values = append(result.values, values)
+	}
+	return result
+}
+
+// tableOf mengambil tabel pertama setelah FROM
+func tableOf(query string) string {
+	idx := strings.Index(query, "FROM `")
+	if idx < 0 {
+		return ""
+	}
+	rest := query[idx+len("FROM `"):]
+	if end := strings.Index(rest, "`"); end >= 0 {
+		return rest[:end]
+	}
+	return ""
+}
+
+// ─── database/sql driver ──────────────────────────────────────────────────────
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	state, ok := instances.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("testdb: database %q sudah ditutup", name)
+	}
+	return &conn{db: state.(*DB)}, nil
+}
+
+type conn struct{ db *DB }
+
+func (c *conn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("testdb: prepare tidak didukung")
+}
+func (c *conn) Close() error              { return nil }
+func (c *conn) Begin() (driver.Tx, error) { c.db.trip(); return c, nil }
+func (c *conn) Commit() error             { c.db.trip(); return nil }
+func (c *conn) Rollback() error           { c.db.trip(); return nil }
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.trip()
+	return result(c.db.exec(query, args)), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.trip()
+	return c.db.query(query, args), nil
+}
+
+type result int64
+
+func (r result) LastInsertId() (int64, error) { return 1, nil }
+func (r result) RowsAffected() (int64, error) { return int64(r), nil }
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,293 @@
+// txstate/txstate.go — state machine status pembayaran & pengiriman transaksi
+package txstate
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	KindPayment    = "payment"
+	KindFulfilment = "fulfilment"
+
+	// Status pengiriman yang disimpan di Transaction.DigiflazzStatus
+	FulfilmentPending    = "pending"
+	FulfilmentProcessing = "processing"
+	FulfilmentSuccess    = "success"
+	FulfilmentFailed     = "failed"
+	FulfilmentCancelled  = "cancelled"
+
+	// Sumber perubahan status
+	SourceGateway          = "gateway"
+	SourcePaymentExpire    = "payment_expire"
+	SourceCustomer         = "customer"
+	SourceAdmin            = "admin"
+	SourceTopupJob         = "topup_job"
+	SourceStatusCheck      = "status_check"
+	SourceDigiflazzWebhook = "digiflazz_webhook"
+	SourceRefund           = "refund"
+)
+
+var ErrIllegalTransition = errors.New("txstate: perpindahan status tidak diizinkan")
+
+// TransitionError menjelaskan perpindahan status yang ditolak
+type TransitionError struct {
+	Kind string
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("txstate: %s %q -> %q tidak diizinkan", e.Kind, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrIllegalTransition
+}
+
+// Perpindahan status pembayaran yang diizinkan.
+// expired -> settlement untuk pembayaran terlambat: expire lokal tetap jalan
+// walaupun cancel di gateway gagal, sehingga gateway masih bisa menerima dana.
+var paymentTransitions = map[string][]string{
+	payment.StatusPending:       {payment.StatusSettlement, payment.StatusFailed, payment.StatusExpired, payment.StatusCancelled},
+	payment.StatusExpired:       {payment.StatusSettlement},
+	payment.StatusSettlement:    {payment.StatusRefundPending, payment.StatusPartialRefund, payment.StatusRefunded},
+	payment.StatusRefundPending: {payment.StatusRefunded, payment.StatusPartialRefund, payment.StatusSettlement},
+	payment.StatusPartialRefund: {payment.StatusRefundPending, payment.StatusRefunded},
+}
+
+// Perpindahan status pengiriman yang diizinkan, "" = belum diproses.
+// success -> failed karena Digiflazz bisa membatalkan transaksi yang sudah sukses,
+// cancelled -> pending untuk pembayaran terlambat setelah expired.
+var fulfilmentTransitions = map[string][]string{
+	"":                   {FulfilmentPending, FulfilmentProcessing, FulfilmentFailed, FulfilmentCancelled},
+	FulfilmentPending:    {FulfilmentProcessing, FulfilmentSuccess, FulfilmentFailed, FulfilmentCancelled},
+	FulfilmentProcessing: {FulfilmentPending, FulfilmentSuccess, FulfilmentFailed},
+	FulfilmentSuccess:    {FulfilmentFailed},
+	FulfilmentCancelled:  {FulfilmentPending},
+}
+
+// Nilai lama yang masih ada di database
+var legacyFulfilment = map[string]string{
+	"Sukses":  FulfilmentSuccess,
+	"Gagal":   FulfilmentFailed,
+	"Pending": FulfilmentPending,
+}
+
+// Nilai payment_status lama. Webhook Digiflazz versi lama menulis "success"
+// saat topup sukses, endpoint update status lama menerima status mentah Midtrans.
+var legacyPayment = map[string]string{
+	"success": payment.StatusSettlement,
+	"capture": payment.StatusSettlement,
+	"expire":  payment.StatusExpired,
+	"cancel":  payment.StatusCancelled,
+	"deny":    payment.StatusFailed,
+	"failure": payment.StatusFailed,
+}
+
+// NormalizePayment mengubah nilai payment_status lama ("success") ke status baku
+func NormalizePayment(status string) string {
+	if normalized, ok := legacyPayment[status]; ok {
+		return normalized
+	}
+	return status
+}
+
+// NormalizeFulfilment mengubah nilai lama ("Sukses", "Gagal") ke status baku
+func NormalizeFulfilment(status string) string {
+	if normalized, ok := legacyFulfilment[status]; ok {
+		return normalized
+	}
+	return status
+}
+
+// CanTransitionPayment true jika status pembayaran boleh berpindah dari from ke to
+func CanTransitionPayment(from, to string) bool {
+	return allowed(paymentTransitions, NormalizePayment(from), to)
+}
+
+// CanTransitionFulfilment true jika status pengiriman boleh berpindah dari from ke to
+func CanTransitionFulfilment(from, to string) bool {
+	return allowed(fulfilmentTransitions, NormalizeFulfilment(from), to)
+}
+
+// IsFinalFulfilment true jika pengiriman sudah selesai dan tidak perlu diproses lagi
+func IsFinalFulfilment(status *string) bool {
+	if status == nil {
+		return false
+	}
+	switch NormalizeFulfilment(*status) {
+	case FulfilmentSuccess, FulfilmentFailed, FulfilmentCancelled:
+		return true
+	}
+	return false
+}
+
+func allowed(transitions map[string][]string, from, to string) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Change adalah perubahan status yang diminta oleh satu penulis
+type Change struct {
+	Payment    string // status pembayaran tujuan, kosong = tidak berubah
+	Fulfilment string // status pengiriman tujuan, kosong = tidak berubah
+	Source     string
+	Note       string
+	Updates    map[string]any // kolom lain yang ikut disimpan
+}
+
+// Result berisi status sebelum & sesudah perubahan
+type Result struct {
+	PaymentFrom    string
+	PaymentTo      string
+	FulfilmentFrom string
+	FulfilmentTo   string
+}
+
+func (r *Result) PaymentChanged() bool {
+	return r.PaymentFrom != r.PaymentTo
+}
+
+func (r *Result) FulfilmentChanged() bool {
+	return r.FulfilmentFrom != r.FulfilmentTo
+}
+
+// Apply memvalidasi dan menyimpan perubahan status transaksi dalam satu
+// database transaction. Baris transaksi dikunci supaya penulis yang berjalan
+// bersamaan (webhook, job, admin) melihat status terbaru. Perpindahan yang
+// tidak diizinkan ditolak dengan *TransitionError, setiap perpindahan dicatat
+// di models.TransactionStatusLog. Field status pada transaction ikut diperbarui.
+func Apply(ctx context.Context, db *gorm.DB, transaction *models.Transaction, change Change) (*Result, error) {
+	var result Result
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "order_id", "payment_status", "digiflazz_status").
+			First(&locked, transaction.ID).Error; err != nil {
+			return err
+		}
+
+		result.PaymentFrom = locked.PaymentStatus
+		result.PaymentTo = NormalizePayment(locked.PaymentStatus)
+		if locked.DigiflazzStatus != nil {
+			result.FulfilmentFrom = *locked.DigiflazzStatus
+		}
+		result.FulfilmentTo = NormalizeFulfilment(result.FulfilmentFrom)
+
+		if change.Payment != "" {
+			if !CanTransitionPayment(result.PaymentFrom, change.Payment) {
+				return &TransitionError{Kind: KindPayment, From: result.PaymentFrom, To: change.Payment}
+			}
+			result.PaymentTo = change.Payment
+		}
+		if change.Fulfilment != "" {
+			if !CanTransitionFulfilment(result.FulfilmentFrom, change.Fulfilment) {
+				return &TransitionError{Kind: KindFulfilment, From: result.FulfilmentFrom, To: change.Fulfilment}
+			}
+			result.FulfilmentTo = change.Fulfilment
+		}
+
+		updates := map[string]any{"updated_at": time.Now()}
+		for k, v := range change.Updates {
+			updates[k] = v
+		}
+		updates["payment_status"] = result.PaymentTo
+		if result.FulfilmentTo != "" {
+			fulfilment := result.FulfilmentTo
+			updates["digiflazz_status"] = &fulfilment
+		}
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", locked.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var logs []models.TransactionStatusLog
+		if result.PaymentChanged() {
+			logs = append(logs, newLog(&locked, KindPayment, result.PaymentFrom, result.PaymentTo, change))
+		}
+		if result.FulfilmentChanged() {
+			logs = append(logs, newLog(&locked, KindFulfilment, result.FulfilmentFrom, result.FulfilmentTo, change))
+		}
+		if len(logs) > 0 {
+			return tx.Create(&logs).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.PaymentStatus = result.PaymentTo
+	if result.FulfilmentTo != "" {
+		fulfilment := result.FulfilmentTo
+		transaction.DigiflazzStatus = &fulfilment
+	}
+
+	if result.PaymentChanged() || result.FulfilmentChanged() {
+		slog.Info("🔀 Status transaksi berubah",
+			"order_id", transaction.OrderID,
+			"source", change.Source,
+			"payment", result.PaymentFrom+" -> "+result.PaymentTo,
+			"fulfilment", result.FulfilmentFrom+" -> "+result.FulfilmentTo,
+		)
+	}
+	return &result, nil
+}
+
+func newLog(transaction *models.Transaction, kind, from, to string, change Change) models.TransactionStatusLog {
+	entry := models.TransactionStatusLog{
+		TransactionID: transaction.ID,
+		OrderID:       transaction.OrderID,
+		Kind:          kind,
+		FromStatus:    from,
+		ToStatus:      to,
+		Source:        change.Source,
+	}
+	if change.Note != "" {
+		note := change.Note
+		entry.Note = &note
+	}
+	return entry
+}
+
+// MigrateLegacy mengubah nilai digiflazz_status & payment_status lama ke status baku
+func MigrateLegacy(db *gorm.DB) error {
+	for legacy, normalized := range legacyFulfilment {
+		if err := db.Model(&models.Transaction{}).
+			Where("digiflazz_status = ?", legacy).
+			Update("digiflazz_status", normalized).Error; err != nil {
+			return err
+		}
+	}
+
+	for legacy, normalized := range legacyPayment {
+		if err := db.Model(&models.Transaction{}).
+			Where("payment_status = ?", legacy).
+			Update("payment_status", normalized).Error; err != nil {
+			return err
+		}
+	}
+
+	// Webhook Digiflazz versi lama juga menulis payment_status failed saat topup
+	// gagal. Order hanya dikirim ke Digiflazz setelah settlement, jadi pembayaran
+	// order yang sudah dikirim sebenarnya diterima dan perlu bisa di-refund.
+	return db.Model(&models.Transaction{}).
+		Where("payment_status = ? AND digiflazz_sent_at IS NOT NULL", payment.StatusFailed).
+		Update("payment_status", payment.StatusSettlement).Error
+}
@@ -0,0 +1,154 @@
+package txstate
+
+import (
+	"api-arveshop-go/models"
+	"api-arveshop-go/payment"
+	"api-arveshop-go/testdb"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for legacy, want := range map[string]string{
+		"success":                payment.StatusSettlement,
+		"capture":                payment.StatusSettlement,
+		"expire":                 payment.StatusExpired,
+		"cancel":                 payment.StatusCancelled,
+		"deny":                   payment.StatusFailed,
+		"failure":                payment.StatusFailed,
+		payment.StatusSettlement: payment.StatusSettlement,
+		payment.StatusPending:    payment.StatusPending,
+	} {
+		if got := NormalizePayment(legacy); got != want {
+			t.Errorf("NormalizePayment(%q) = %q, want %q", legacy, got, want)
+		}
+	}
+
+	for legacy, want := range map[string]string{
+		"Sukses":          FulfilmentSuccess,
+		"Gagal":           FulfilmentFailed,
+		"Pending":         FulfilmentPending,
+		FulfilmentSuccess: FulfilmentSuccess,
+		"":                "",
+	} {
+		if got := NormalizeFulfilment(legacy); got != want {
+			t.Errorf("NormalizeFulfilment(%q) = %q, want %q", legacy, got, want)
+		}
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	payments := []struct {
+		from, to string
+		want     bool
+	}{
+		{payment.StatusPending, payment.StatusSettlement, true},
+		{payment.StatusSettlement, payment.StatusRefundPending, true},
+		{payment.StatusSettlement, payment.StatusPending, false},
+		{payment.StatusRefunded, payment.StatusSettlement, false},
+		// Nilai lama dari webhook Digiflazz tetap bisa di-refund
+		{"success", payment.StatusRefundPending, true},
+		{"success", payment.StatusPending, false},
+	}
+	for _, tt := range payments {
+		if got := CanTransitionPayment(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionPayment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	fulfilments := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", FulfilmentProcessing, true},
+		{FulfilmentProcessing, FulfilmentSuccess, true},
+		{FulfilmentSuccess, FulfilmentFailed, true},
+		{FulfilmentFailed, FulfilmentSuccess, false},
+		{"Sukses", FulfilmentFailed, true},
+		{"Gagal", FulfilmentSuccess, false},
+	}
+	for _, tt := range fulfilments {
+		if got := CanTransitionFulfilment(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionFulfilment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	db, fake := testdb.Open(t)
+	if err := MigrateLegacy(db); err != nil {
+		t.Fatal(err)
+	}
+
+	updates := fake.Execs("UPDATE `transactions`")
+	if want := len(legacyFulfilment) + len(legacyPayment) + 1; len(updates) != want {
+		t.Fatalf("update transactions = %d, want %d", len(updates), want)
+	}
+
+	found := func(column, from, to string) bool {
+		for _, update := range updates {
+			if strings.Contains(update.Query, "SET `"+column+"`") && update.Has(from) && update.Has(to) {
+				return true
+			}
+		}
+		return false
+	}
+	for legacy, normalized := range legacyFulfilment {
+		if !found("digiflazz_status", legacy, normalized) {
+			t.Errorf("digiflazz_status %q -> %q tidak dinormalisasi", legacy, normalized)
+		}
+	}
+	for legacy, normalized := range legacyPayment {
+		if !found("payment_status", legacy, normalized) {
+			t.Errorf("payment_status %q -> %q tidak dinormalisasi", legacy, normalized)
+		}
+	}
+
+	// failed dari webhook Digiflazz lama pada order yang sudah dikirim
+	last := updates[len(updates)-1]
+	if !strings.Contains(last.Query, "digiflazz_sent_at IS NOT NULL") || !last.Has(payment.StatusFailed) || !last.Has(payment.StatusSettlement) {
+		t.Errorf("update failed setelah dikirim = %+v", last)
+	}
+}
+
+func TestApply(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	t.Run("nilai lama dinormalisasi", func(t *testing.T) {
+		db, fake := testdb.Open(t)
+		fake.SetRows("transactions", testdb.Row{"id": int64(1), "order_id": "ARV-1", "payment_status": "success", "digiflazz_status": "Sukses"})
+
+		order := &models.Transaction{ID: 1, OrderID: "ARV-1"}
+		result, err := Apply(context.Background(), db, order, Change{Payment: payment.StatusRefundPending, Source: SourceAdmin})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.PaymentTo != payment.StatusRefundPending || result.FulfilmentTo != FulfilmentSuccess {
+			t.Errorf("result = %+v", result)
+		}
+		if order.PaymentStatus != payment.StatusRefundPending {
+			t.Errorf("order.PaymentStatus = %q", order.PaymentStatus)
+		}
+		if logs := fake.Execs("INSERT INTO `transaction_status_logs`"); len(logs) != 1 {
+			t.Errorf("insert status log = %d, want 1", len(logs))
+		}
+	})
+
+	t.Run("perpindahan ditolak", func(t *testing.T) {
+		db, fake := testdb.Open(t)
+		fake.SetRows("transactions", testdb.Row{"id": int64(1), "order_id": "ARV-1", "payment_status": payment.StatusRefunded})
+
+		order := &models.Transaction{ID: 1, OrderID: "ARV-1"}
+		_, err := Apply(context.Background(), db, order, Change{Payment: payment.StatusSettlement, Source: SourceAdmin})
+		if !errors.Is(err, ErrIllegalTransition) {
+			t.Fatalf("Apply() error = %v, want ErrIllegalTransition", err)
+		}
+		if updates := fake.Execs("UPDATE `transactions`"); len(updates) != 0 {
+			t.Errorf("update transactions = %d, want 0", len(updates))
+		}
+	})
+}
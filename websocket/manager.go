@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -20,7 +21,7 @@ type Client struct {
 
 // Message structure for WebSocket communication
 type Message struct {
-	Type    string      `json:"type"`               // "subscribe", "unsubscribe", "order_update", "ping", "pong"
+	Type    string      `json:"type"`               // "subscribe", "unsubscribe", "order_update", "order_scheduled", "ping", "pong"
 	OrderID string      `json:"order_id,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
@@ -82,11 +83,28 @@ func (manager *WebSocketManager) start() {
 
 // SendToOrderSubscribers sends update to all clients subscribed to an order
 func (manager *WebSocketManager) SendToOrderSubscribers(orderID string, data interface{}) {
-	message := Message{
+	manager.sendMessage(Message{
 		Type:    "order_update", // KONSISTEN: selalu pakai "order_update"
 		OrderID: orderID,
 		Data:    data,
-	}
+	})
+}
+
+// BroadcastOrderScheduled memberi tahu pembeli kapan pesanan akan diproses
+// (misal produk sedang cutoff)
+func BroadcastOrderScheduled(orderID string, processAt time.Time, reason string) {
+	Manager.sendMessage(Message{
+		Type:    "order_scheduled",
+		OrderID: orderID,
+		Data: map[string]interface{}{
+			"process_at": processAt,
+			"reason":     reason,
+		},
+	})
+}
+
+func (manager *WebSocketManager) sendMessage(message Message) {
+	orderID := message.OrderID
 	
 	jsonMessage, err := json.Marshal(message)
 	if err != nil {
@@ -103,7 +121,7 @@ func (manager *WebSocketManager) SendToOrderSubscribers(orderID string, data int
 			select {
 			case client.Send <- jsonMessage:
 				sentCount++
-				log.Printf("📤 Sent update for order %s to client %s", orderID, client.UserID)
+				log.Printf("📤 Sent %s for order %s to client %s", message.Type, orderID, client.UserID)
 			default:
 				log.Printf("⚠️ Client %s buffer full, closing", client.UserID)
 				close(client.Send)